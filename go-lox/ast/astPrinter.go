@@ -22,6 +22,64 @@ func (a *AstPrinter) VisitReturn(s Sreturn) interface{} {
 	return a.parenthesize("return", s.Value)
 }
 
+func (a *AstPrinter) VisitThrow(s Sthrow) interface{} {
+	return a.parenthesize("throw", s.Value)
+}
+
+func (a *AstPrinter) VisitTry(s Stry) interface{} {
+	ret := "(try " + a.parenthesizeStmts("body", s.Body...)
+	if s.CatchName != nil {
+		ret += " " + a.parenthesizeStmts("catch "+s.CatchName.Lexeme, s.CatchBody...)
+	}
+	if s.FinallyBody != nil {
+		ret += " " + a.parenthesizeStmts("finally", s.FinallyBody...)
+	}
+	return ret + ")"
+}
+
+func (a *AstPrinter) VisitMatch(s Smatch) interface{} {
+	ret := []string{"(match ", a.PrintExpr(s.Subject)}
+	for _, c := range s.Cases {
+		patterns := make([]string, len(c.Patterns))
+		for idx, pattern := range c.Patterns {
+			patterns[idx] = a.PrintPattern(pattern)
+		}
+		ret = append(ret, " (case ", strings.Join(patterns, ", "), " ", a.PrintStatement(c.Body), ")")
+	}
+	if s.Default != nil {
+		ret = append(ret, " (default ", a.PrintStatement(s.Default), ")")
+	}
+	ret = append(ret, ")")
+	return strings.Join(ret, "")
+}
+
+func (a *AstPrinter) PrintPattern(p Pattern) string {
+	switch v := p.(type) {
+	case Pliteral:
+		return a.VisitLiteral(Literal{Value: v.Value}).(string)
+	case Pwildcard:
+		return "_"
+	case Pbinding:
+		return v.Name.Lexeme
+	case Plist:
+		elements := make([]string, len(v.Elements))
+		for idx, element := range v.Elements {
+			elements[idx] = a.PrintPattern(element)
+		}
+		if v.Rest != nil {
+			elements = append(elements, "..."+v.Rest.Lexeme)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case Pmap:
+		entries := make([]string, len(v.Keys))
+		for idx, key := range v.Keys {
+			entries[idx] = fmt.Sprintf("%v: %s", key, a.PrintPattern(v.Values[idx]))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+	panic("Unreachable")
+}
+
 func (a *AstPrinter) VisitIf(s Sif) interface{} {
 	if s.ElseBranch != nil {
 		return fmt.Sprintf(
@@ -44,10 +102,30 @@ func (a *AstPrinter) VisitExpression(s Sexpression) interface{} {
 }
 
 func (a *AstPrinter) VisitVar(s Svar) interface{} {
+	keyword := "var "
+	if s.Const {
+		keyword = "const "
+	}
 	if s.Expression == nil {
-		return a.parenthesize("var " + s.Name.Lexeme)
+		return a.parenthesize(keyword + s.Name.Lexeme)
+	}
+	return a.parenthesize(keyword+s.Name.Lexeme, s.Expression)
+}
+
+func (a *AstPrinter) VisitDestructure(s Sdestructure) interface{} {
+	keyword := "var "
+	if s.Const {
+		keyword = "const "
 	}
-	return a.parenthesize("var "+s.Name.Lexeme, s.Expression)
+	return a.parenthesize(keyword+a.PrintPattern(s.Pattern), s.Expression)
+}
+
+func (a *AstPrinter) VisitImport(s Simport) interface{} {
+	return fmt.Sprintf("(import %q as %s)", s.Path, s.Name.Lexeme)
+}
+
+func (a *AstPrinter) VisitExport(s Sexport) interface{} {
+	return fmt.Sprintf("(export %s)", a.PrintStatement(s.Declaration))
 }
 
 func (a *AstPrinter) VisitFunction(s Sfunction) interface{} {
@@ -73,7 +151,24 @@ func (a *AstPrinter) PrintExpr(e Expr) string {
 }
 
 func (a *AstPrinter) VisitCall(e Ecall) interface{} {
-	return a.parenthesize("call "+a.PrintExpr(e.Callee), e.Args...)
+	call := a.parenthesize("call "+a.PrintExpr(e.Callee), e.Args...)
+	if len(e.Named) == 0 {
+		return call
+	}
+	ret := []string{strings.TrimSuffix(call, ")")}
+	for _, arg := range e.Named {
+		ret = append(ret, " ", arg.Name.Lexeme, ": ", a.PrintExpr(arg.Value))
+	}
+	ret = append(ret, ")")
+	return strings.Join(ret, "")
+}
+
+func (a *AstPrinter) VisitGet(e Eget) interface{} {
+	return a.parenthesize("get "+e.Name.Lexeme, e.Object)
+}
+
+func (a *AstPrinter) VisitSet(e Eset) interface{} {
+	return a.parenthesize("set "+e.Name.Lexeme, e.Object, e.Value)
 }
 
 func (a *AstPrinter) VisitVariable(e Evariable) interface{} {
@@ -92,6 +187,37 @@ func (a *AstPrinter) VisitAssign(e Eassign) interface{} {
 	return a.parenthesize("assign "+e.Name.Lexeme, e.Value)
 }
 
+func (a *AstPrinter) VisitCompound(e Ecompound) interface{} {
+	return a.parenthesize(e.Op.Lexeme, e.Target, e.Value)
+}
+
+func (a *AstPrinter) VisitIncrement(e Eincrement) interface{} {
+	if e.Prefix {
+		return a.parenthesize("pre"+e.Op.Lexeme, e.Target)
+	}
+	return a.parenthesize("post"+e.Op.Lexeme, e.Target)
+}
+
+func (a *AstPrinter) VisitList(e Elist) interface{} {
+	return a.parenthesize("list", e.Elements...)
+}
+
+func (a *AstPrinter) VisitMap(e Emap) interface{} {
+	entries := make([]Expr, 0, 2*len(e.Keys))
+	for idx, key := range e.Keys {
+		entries = append(entries, key, e.Values[idx])
+	}
+	return a.parenthesize("map", entries...)
+}
+
+func (a *AstPrinter) VisitIndex(e Eindex) interface{} {
+	return a.parenthesize("index", e.Object, e.Index)
+}
+
+func (a *AstPrinter) VisitIndexSet(e EindexSet) interface{} {
+	return a.parenthesize("index-set", e.Object, e.Index, e.Value)
+}
+
 func (a *AstPrinter) VisitLiteral(e Literal) interface{} {
 	if e.Value == nil {
 		return "nil"
@@ -103,6 +229,14 @@ func (a *AstPrinter) VisitGrouping(e Grouping) interface{} {
 	return a.parenthesize("group", e.Expression)
 }
 
+func (a *AstPrinter) VisitInterpolation(e Einterpolation) interface{} {
+	return a.parenthesize("interpolate", e.Parts...)
+}
+
+func (a *AstPrinter) VisitConditional(e Econditional) interface{} {
+	return a.parenthesize("?:", e.Condition, e.ThenBranch, e.ElseBranch)
+}
+
 func (a *AstPrinter) VisitLogical(e Elogical) interface{} {
 	return a.parenthesize(e.Op.Lexeme, e.Left, e.Right)
 }
@@ -15,6 +15,16 @@ type ExprVisitor interface {
 	VisitAssign(Eassign) interface{}
 	VisitLogical(Elogical) interface{}
 	VisitCall(Ecall) interface{}
+	VisitInterpolation(Einterpolation) interface{}
+	VisitGet(Eget) interface{}
+	VisitSet(Eset) interface{}
+	VisitConditional(Econditional) interface{}
+	VisitCompound(Ecompound) interface{}
+	VisitIncrement(Eincrement) interface{}
+	VisitList(Elist) interface{}
+	VisitIndex(Eindex) interface{}
+	VisitIndexSet(EindexSet) interface{}
+	VisitMap(Emap) interface{}
 }
 
 type Binary struct {
@@ -55,13 +65,90 @@ type Ecall struct {
 	Callee Expr
 	Paren  token.Token
 	Args   []Expr
+	// Named holds the `name: value` arguments, which follow the positional
+	// Args in the source
+	Named []NamedArg
 }
 
-func (b Binary) Accept(e ExprVisitor) interface{}    { return e.VisitBinary(b) }
-func (g Grouping) Accept(e ExprVisitor) interface{}  { return e.VisitGrouping(g) }
-func (l Literal) Accept(e ExprVisitor) interface{}   { return e.VisitLiteral(l) }
-func (u Unary) Accept(e ExprVisitor) interface{}     { return e.VisitUnary(u) }
-func (u Evariable) Accept(e ExprVisitor) interface{} { return e.VisitVariable(u) }
-func (u Eassign) Accept(e ExprVisitor) interface{}   { return e.VisitAssign(u) }
-func (u Elogical) Accept(e ExprVisitor) interface{}  { return e.VisitLogical(u) }
-func (u Ecall) Accept(e ExprVisitor) interface{}     { return e.VisitCall(u) }
+type NamedArg struct {
+	Name  token.Token
+	Value Expr
+}
+
+type Einterpolation struct {
+	Parts []Expr
+}
+
+type Eget struct {
+	Object Expr
+	Name   token.Token
+}
+
+type Eset struct {
+	Object Expr
+	Name   token.Token
+	Value  Expr
+}
+
+type Econditional struct {
+	Condition  Expr
+	ThenBranch Expr
+	ElseBranch Expr
+}
+
+// Ecompound is a compound assignment like `a += 1`
+type Ecompound struct {
+	Target Expr
+	Op     token.Token
+	Value  Expr
+}
+
+// Eincrement is `++` or `--`, either prefix or postfix
+type Eincrement struct {
+	Target Expr
+	Op     token.Token
+	Prefix bool
+}
+
+type Elist struct {
+	Bracket  token.Token
+	Elements []Expr
+}
+
+type Emap struct {
+	Brace  token.Token
+	Keys   []Expr
+	Values []Expr
+}
+
+type Eindex struct {
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+}
+
+type EindexSet struct {
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+	Value   Expr
+}
+
+func (b Binary) Accept(e ExprVisitor) interface{}         { return e.VisitBinary(b) }
+func (g Grouping) Accept(e ExprVisitor) interface{}       { return e.VisitGrouping(g) }
+func (l Literal) Accept(e ExprVisitor) interface{}        { return e.VisitLiteral(l) }
+func (u Unary) Accept(e ExprVisitor) interface{}          { return e.VisitUnary(u) }
+func (u Evariable) Accept(e ExprVisitor) interface{}      { return e.VisitVariable(u) }
+func (u Eassign) Accept(e ExprVisitor) interface{}        { return e.VisitAssign(u) }
+func (u Elogical) Accept(e ExprVisitor) interface{}       { return e.VisitLogical(u) }
+func (u Ecall) Accept(e ExprVisitor) interface{}          { return e.VisitCall(u) }
+func (u Einterpolation) Accept(e ExprVisitor) interface{} { return e.VisitInterpolation(u) }
+func (u Eget) Accept(e ExprVisitor) interface{}           { return e.VisitGet(u) }
+func (u Eset) Accept(e ExprVisitor) interface{}           { return e.VisitSet(u) }
+func (u Econditional) Accept(e ExprVisitor) interface{}   { return e.VisitConditional(u) }
+func (u Ecompound) Accept(e ExprVisitor) interface{}      { return e.VisitCompound(u) }
+func (u Eincrement) Accept(e ExprVisitor) interface{}     { return e.VisitIncrement(u) }
+func (u Elist) Accept(e ExprVisitor) interface{}          { return e.VisitList(u) }
+func (u Eindex) Accept(e ExprVisitor) interface{}         { return e.VisitIndex(u) }
+func (u EindexSet) Accept(e ExprVisitor) interface{}      { return e.VisitIndexSet(u) }
+func (u Emap) Accept(e ExprVisitor) interface{}           { return e.VisitMap(u) }
@@ -0,0 +1,69 @@
+package ast
+
+import "github.com/vn-ki/go-lox/token"
+
+// Patterns are matched against values in `match` statements. They are few
+// and only looked at by a couple of passes, so they use a type switch
+// instead of a visitor.
+type Pattern interface {
+	pattern()
+}
+
+// Pliteral matches values equal to a literal
+type Pliteral struct {
+	Value interface{}
+}
+
+// Pwildcard is `_`, it matches anything
+type Pwildcard struct{}
+
+// Pbinding matches anything and binds it to Name
+type Pbinding struct {
+	Name token.Token
+}
+
+// Plist matches lists element by element. Without a rest pattern the list
+// has to have exactly as many elements.
+type Plist struct {
+	Bracket  token.Token
+	Elements []Pattern
+	// the `...rest` pattern, if any
+	Rest *token.Token
+}
+
+// Pmap matches maps having all of Keys, with the values matching Values
+type Pmap struct {
+	Brace  token.Token
+	Keys   []interface{}
+	Values []Pattern
+}
+
+func (Pliteral) pattern()  {}
+func (Pwildcard) pattern() {}
+func (Pbinding) pattern()  {}
+func (Plist) pattern()     {}
+func (Pmap) pattern()      {}
+
+// PatternBindings returns the names a pattern binds, in order
+func PatternBindings(p Pattern) []token.Token {
+	switch v := p.(type) {
+	case Pbinding:
+		return []token.Token{v.Name}
+	case Plist:
+		names := make([]token.Token, 0)
+		for _, element := range v.Elements {
+			names = append(names, PatternBindings(element)...)
+		}
+		if v.Rest != nil && v.Rest.Lexeme != "_" {
+			names = append(names, *v.Rest)
+		}
+		return names
+	case Pmap:
+		names := make([]token.Token, 0)
+		for _, value := range v.Values {
+			names = append(names, PatternBindings(value)...)
+		}
+		return names
+	}
+	return nil
+}
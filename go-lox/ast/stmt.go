@@ -15,6 +15,12 @@ type StmtVisitor interface {
 	VisitWhile(Swhile) interface{}
 	VisitFunction(Sfunction) interface{}
 	VisitReturn(Sreturn) interface{}
+	VisitThrow(Sthrow) interface{}
+	VisitTry(Stry) interface{}
+	VisitMatch(Smatch) interface{}
+	VisitDestructure(Sdestructure) interface{}
+	VisitImport(Simport) interface{}
+	VisitExport(Sexport) interface{}
 }
 
 type Sexpression struct {
@@ -28,6 +34,29 @@ type Sprint struct {
 type Svar struct {
 	Name       token.Token
 	Expression Expr
+	Const      bool
+}
+
+// Sdestructure is a `var` or `const` declaration with a list or map pattern
+// instead of a name, like `var [a, b] = xs;`
+type Sdestructure struct {
+	Keyword    token.Token
+	Pattern    Pattern
+	Expression Expr
+	Const      bool
+}
+
+type Simport struct {
+	Keyword token.Token
+	// the path of the module, relative to the importing file
+	Path string
+	Name token.Token
+}
+
+// Sexport is a top level declaration which other modules can import
+type Sexport struct {
+	Keyword     token.Token
+	Declaration Stmt
 }
 
 type Sblock struct {
@@ -48,7 +77,11 @@ type Swhile struct {
 type Sfunction struct {
 	Name   token.Token
 	Params []token.Token
-	Body   []Stmt
+	// default value of each parameter, nil for required parameters
+	Defaults []Expr
+	// the `...rest` parameter, if any
+	Rest *token.Token
+	Body []Stmt
 }
 
 type Sreturn struct {
@@ -56,11 +89,45 @@ type Sreturn struct {
 	Keyword token.Token
 }
 
-func (t Sexpression) Accept(s StmtVisitor) interface{} { return s.VisitExpression(t) }
-func (t Sprint) Accept(s StmtVisitor) interface{}      { return s.VisitPrint(t) }
-func (t Svar) Accept(s StmtVisitor) interface{}        { return s.VisitVar(t) }
-func (t Sblock) Accept(s StmtVisitor) interface{}      { return s.VisitBlock(t) }
-func (t Sif) Accept(s StmtVisitor) interface{}         { return s.VisitIf(t) }
-func (t Swhile) Accept(s StmtVisitor) interface{}      { return s.VisitWhile(t) }
-func (t Sfunction) Accept(s StmtVisitor) interface{}   { return s.VisitFunction(t) }
-func (t Sreturn) Accept(s StmtVisitor) interface{}     { return s.VisitReturn(t) }
+type Sthrow struct {
+	Keyword token.Token
+	Value   Expr
+}
+
+type Stry struct {
+	Body []Stmt
+	// CatchName and CatchBody are nil if there is no catch clause
+	CatchName *token.Token
+	CatchBody []Stmt
+	// nil if there is no finally clause
+	FinallyBody []Stmt
+}
+
+type Smatch struct {
+	Keyword token.Token
+	Subject Expr
+	Cases   []MatchCase
+	// nil if there is no default clause
+	Default Stmt
+}
+
+type MatchCase struct {
+	// the case matches if any of the patterns match
+	Patterns []Pattern
+	Body     Stmt
+}
+
+func (t Sexpression) Accept(s StmtVisitor) interface{}  { return s.VisitExpression(t) }
+func (t Sprint) Accept(s StmtVisitor) interface{}       { return s.VisitPrint(t) }
+func (t Svar) Accept(s StmtVisitor) interface{}         { return s.VisitVar(t) }
+func (t Sblock) Accept(s StmtVisitor) interface{}       { return s.VisitBlock(t) }
+func (t Sif) Accept(s StmtVisitor) interface{}          { return s.VisitIf(t) }
+func (t Swhile) Accept(s StmtVisitor) interface{}       { return s.VisitWhile(t) }
+func (t Sfunction) Accept(s StmtVisitor) interface{}    { return s.VisitFunction(t) }
+func (t Sreturn) Accept(s StmtVisitor) interface{}      { return s.VisitReturn(t) }
+func (t Sthrow) Accept(s StmtVisitor) interface{}       { return s.VisitThrow(t) }
+func (t Stry) Accept(s StmtVisitor) interface{}         { return s.VisitTry(t) }
+func (t Smatch) Accept(s StmtVisitor) interface{}       { return s.VisitMatch(t) }
+func (t Sdestructure) Accept(s StmtVisitor) interface{} { return s.VisitDestructure(t) }
+func (t Simport) Accept(s StmtVisitor) interface{}      { return s.VisitImport(t) }
+func (t Sexport) Accept(s StmtVisitor) interface{}      { return s.VisitExport(t) }
@@ -1,24 +1,41 @@
 package env
 
 import (
+	"fmt"
 	"log"
 	"strings"
+
+	"github.com/vn-ki/go-lox/value"
 )
 
 type Environemnt struct {
-	values    map[string]interface{}
+	values map[string]value.Value
+	// names defined with `const`
+	constants map[string]bool
 	Enclosing *Environemnt
 }
 
 func NewEnvironment(enclosing *Environemnt) *Environemnt {
-	return &Environemnt{make(map[string]interface{}), enclosing}
+	return &Environemnt{make(map[string]value.Value), make(map[string]bool), enclosing}
 }
 
-func (e *Environemnt) Define(key string, val interface{}) {
+func (e *Environemnt) Define(key string, val value.Value) {
 	e.values[key] = val
+	delete(e.constants, key)
 }
 
-func (e *Environemnt) Get(key string) (interface{}, bool) {
+func (e *Environemnt) DefineConst(key string, val value.Value) {
+	e.values[key] = val
+	e.constants[key] = true
+}
+
+// IsConstant reports whether key is a constant defined in e itself, not in
+// an enclosing environment
+func (e *Environemnt) IsConstant(key string) bool {
+	return e.constants[key]
+}
+
+func (e *Environemnt) Get(key string) (value.Value, bool) {
 	val, ok := e.values[key]
 	if !ok && e.Enclosing != nil {
 		return e.Enclosing.Get(key)
@@ -26,15 +43,20 @@ func (e *Environemnt) Get(key string) (interface{}, bool) {
 	return val, ok
 }
 
-func (e *Environemnt) Assign(key string, value interface{}) bool {
-	_, ok := e.values[key]
-	if ok {
-		e.Define(key, value)
-	} else if e.Enclosing != nil {
-		ok = e.Enclosing.Assign(key, value)
+// Assign sets an existing variable. It fails if the variable is not defined
+// or is a constant.
+func (e *Environemnt) Assign(key string, val value.Value) error {
+	if _, ok := e.values[key]; ok {
+		if e.constants[key] {
+			return fmt.Errorf("cannot assign to constant '%s'", key)
+		}
+		e.values[key] = val
+		return nil
 	}
-
-	return ok
+	if e.Enclosing != nil {
+		return e.Enclosing.Assign(key, val)
+	}
+	return fmt.Errorf("Undefined variable '%s'", key)
 }
 
 func (e *Environemnt) DumpEnv() {
@@ -0,0 +1,158 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/vn-ki/go-lox/ast"
+	"github.com/vn-ki/go-lox/token"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// Value is a lox value. Embedders make values with value.NewNumber and the
+// other constructors of the value package, or convert Go values with ToValue.
+type Value = value.Value
+
+/// Native Function
+
+// NativeFunction is a Go function registered with RegisterNative
+type NativeFunction struct {
+	name string
+	// the least and most number of arguments, max is -1 for any number
+	min, max int
+	fn       func(args []Value) (Value, error)
+}
+
+// nativeError is the panic value of an error returned by a native. It
+// becomes a runtimeError at the call site.
+type nativeError struct {
+	error
+}
+
+func (f *NativeFunction) Arity() (int, int) { return f.min, f.max }
+
+func (f *NativeFunction) Call(_ *Interpreter, args []Value) Value {
+	ret, err := f.fn(args)
+	if err != nil {
+		panic(nativeError{err})
+	}
+	return ret
+}
+
+func (f *NativeFunction) TypeName() string { return "function" }
+
+func (f *NativeFunction) String() string { return "<native fn>" }
+
+// RegisterNative defines a global native function called name. arity is the
+// number of arguments fn takes, or -1 for any number. An error returned by fn
+// is a runtime error in the calling script. Registered natives are also
+// defined in imported modules.
+func (i *Interpreter) RegisterNative(name string, arity int, fn func(args []Value) (Value, error)) {
+	native := newNative(name, arity, fn)
+	i.bindings[name] = native
+	i.globals.Define(name, native)
+}
+
+// newNative returns a native function value. arity is the number of
+// arguments, or -1 for any number.
+func newNative(name string, arity int, fn func(args []Value) (Value, error)) Value {
+	if arity < 0 {
+		return newNativeRange(name, 0, -1, fn)
+	}
+	return newNativeRange(name, arity, arity, fn)
+}
+
+// newNativeRange returns a native function value which takes between min and
+// max arguments, so the last max-min arguments are optional
+func newNativeRange(name string, min, max int, fn func(args []Value) (Value, error)) Value {
+	return value.NewObject(&NativeFunction{name: name, min: min, max: max, fn: fn})
+}
+
+/// Errors
+
+// RuntimeError is the error returned to Go when a script fails with a
+// runtime error or an uncaught exception
+type RuntimeError struct {
+	Message string
+	Line    int
+	// Value is the thrown value of an uncaught exception
+	Value Value
+}
+
+func (e *RuntimeError) Error() string { return fmt.Sprintf("[line %d] %s", e.Line, e.Message) }
+
+// protect runs f and returns the runtime error or uncaught exception it
+// panics with, if any
+func (i *Interpreter) protect(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch w := r.(type) {
+			case runtimeError:
+				err = &RuntimeError{Message: w.Error(), Line: w.token.Line}
+			case throwError:
+				err = &RuntimeError{
+					Message: "Uncaught exception: " + w.Value.String(),
+					Line:    w.token.Line,
+					Value:   w.Value,
+				}
+			default:
+				panic(r)
+			}
+		}
+	}()
+	f()
+	return nil
+}
+
+/// Embedding
+
+// Call calls the global function called name. args are converted with
+// ToValue, so they can be Values or plain Go values.
+func (i *Interpreter) Call(name string, args ...interface{}) (result Value, err error) {
+	callee, ok := i.globals.Get(name)
+	if !ok {
+		return value.Nil, fmt.Errorf("undefined function '%s'", name)
+	}
+	fun, ok := callee.AsObject().(LoxCallable)
+	if !ok {
+		return value.Nil, fmt.Errorf("'%s' is not callable", name)
+	}
+	values := make([]Value, len(args))
+	for idx, arg := range args {
+		if values[idx], err = ToValue(arg); err != nil {
+			return value.Nil, fmt.Errorf("argument %d of '%s': %v", idx+1, name, err)
+		}
+	}
+	if min, max := fun.Arity(); len(values) < min || (max >= 0 && len(values) > max) {
+		return value.Nil, fmt.Errorf("'%s' %s", name, arityMessage(min, max, len(values)))
+	}
+
+	tok := token.Token{Type: token.Tidentifier, Lexeme: name}
+	err = i.protect(func() {
+		result = i.call(fun, values, tok)
+	})
+	return result, err
+}
+
+// EvalString runs src in the global environment. If the last statement is an
+// expression its value is returned. The semicolon after it can be left out,
+// so that `EvalString("price > 100")` works.
+func (i *Interpreter) EvalString(src string) (result Value, err error) {
+	stmts, err := parseSource(src)
+	if err != nil {
+		var retryErr error
+		if stmts, retryErr = parseSource(src + ";"); retryErr != nil {
+			return value.Nil, err
+		}
+	}
+
+	err = i.protect(func() {
+		for idx, stmt := range stmts {
+			if expr, ok := stmt.(ast.Sexpression); ok && idx == len(stmts)-1 {
+				result = i.Evaluate(expr.Expression)
+				return
+			}
+			i.execute(stmt)
+		}
+	})
+	return result, err
+}
@@ -0,0 +1,62 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/vn-ki/go-lox/token"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// throwError is the panic value of a lox `throw` statement
+type throwError struct {
+	Value Value
+	token token.Token
+}
+
+// LoxObject is a value whose properties can be read with `.`
+type LoxObject interface {
+	value.Object
+	Get(name token.Token) (Value, bool)
+}
+
+// LoxMutableObject is a LoxObject whose properties can also be assigned with
+// `.`. Set returns an error if the property can't be set to value.
+type LoxMutableObject interface {
+	LoxObject
+	Set(name token.Token, value Value) error
+}
+
+/// Error object
+
+// LoxError is what a runtime error looks like once it is caught by a lox
+// `catch` clause.
+type LoxError struct {
+	Message string
+	Line    int
+}
+
+func (e LoxError) Get(name token.Token) (Value, bool) {
+	switch name.Lexeme {
+	case "message":
+		return value.NewString(e.Message), true
+	case "line":
+		return value.NewNumber(float64(e.Line)), true
+	}
+	return value.Nil, false
+}
+
+func (e LoxError) TypeName() string { return "error" }
+
+func (e LoxError) String() string { return fmt.Sprintf("<error: %s>", e.Message) }
+
+// caught converts a recovered panic into the value bound by `catch`.
+// ok is false for panics which lox code can't catch, like returns.
+func caught(r interface{}) (val Value, ok bool) {
+	switch w := r.(type) {
+	case throwError:
+		return w.Value, true
+	case runtimeError:
+		return value.NewObject(LoxError{Message: w.Error(), Line: w.token.Line}), true
+	}
+	return value.Nil, false
+}
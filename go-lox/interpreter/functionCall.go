@@ -7,48 +7,132 @@ import (
 	"github.com/vn-ki/go-lox/ast"
 	"github.com/vn-ki/go-lox/env"
 	"github.com/vn-ki/go-lox/token"
+	"github.com/vn-ki/go-lox/value"
 )
 
 type LoxCallable interface {
-	Call(*Interpreter, []interface{}) interface{}
-	Arity() int
+	value.Object
+	Call(*Interpreter, []Value) Value
+	// Arity returns the minimum and maximum number of arguments.
+	// max is -1 if there is no maximum.
+	Arity() (min int, max int)
+}
+
+// ParamNamer is implemented by callables which accept named arguments
+type ParamNamer interface {
+	// ParamNames returns the names of the parameters, in order
+	ParamNames() []string
+}
+
+// missingArg marks an optional parameter skipped over by named arguments
+type missingArg struct{}
+
+func (m missingArg) TypeName() string { return "missing argument" }
+
+func isMissingArg(v Value) bool {
+	_, ok := v.AsObject().(missingArg)
+	return ok
 }
 
 /// Native Function: clock
+
+// FnClock returns the time in seconds, with a fractional part, from the
+// interpreter's Clock
 type FnClock struct{}
 
-func (f FnClock) Arity() int { return 0 }
+func (f FnClock) Arity() (int, int) { return 0, 0 }
+
+func (f FnClock) Call(i *Interpreter, _ []Value) Value {
+	now := i.clock.Now()
+	return value.NewNumber(float64(now.Unix()) + float64(now.Nanosecond())/float64(time.Second))
+}
+
+func (f FnClock) TypeName() string { return "function" }
+
+func (f FnClock) String() string { return "<native fn>" }
+
+/// Native Function: str
 
-func (f FnClock) Call(_ *Interpreter, _ []interface{}) interface{} {
-	return float64(time.Now().UnixNano())
+// FnStr converts a value to a string the way print formats it
+type FnStr struct{}
+
+func (f FnStr) Arity() (int, int) { return 1, 1 }
+
+func (f FnStr) Call(_ *Interpreter, args []Value) Value {
+	return value.NewString(args[0].String())
 }
 
-func (f FnClock) String() string { return "<clock native fn>" }
+func (f FnStr) TypeName() string { return "function" }
+
+func (f FnStr) String() string { return "<native fn>" }
 
 /// Lox Function
 
 type LoxFunction struct {
-	Name   token.Token
-	Params []token.Token
-	Body   []ast.Stmt
-	Env    *env.Environemnt
+	Name     token.Token
+	Params   []token.Token
+	Defaults []ast.Expr
+	Rest     *token.Token
+	Body     []ast.Stmt
+	Env      *env.Environemnt
+}
+
+// NewLoxFunctionFromAst returns a new function object. Functions are compared
+// by identity, so each declaration that runs makes a different function.
+func NewLoxFunctionFromAst(f ast.Sfunction, env *env.Environemnt) *LoxFunction {
+	return &LoxFunction{
+		Name:     f.Name,
+		Params:   f.Params,
+		Defaults: f.Defaults,
+		Rest:     f.Rest,
+		Body:     f.Body,
+		Env:      env,
+	}
 }
 
-func NewLoxFunctionFromAst(f ast.Sfunction, env *env.Environemnt) LoxFunction {
-	return LoxFunction{Name: f.Name, Params: f.Params, Body: f.Body, Env: env}
+func (f *LoxFunction) Arity() (int, int) {
+	min := 0
+	for min < len(f.Params) && f.Defaults[min] == nil {
+		min++
+	}
+	if f.Rest != nil {
+		return min, -1
+	}
+	return min, len(f.Params)
 }
 
-func (f LoxFunction) Arity() int { return len(f.Params) }
+func (f *LoxFunction) ParamNames() []string {
+	names := make([]string, len(f.Params))
+	for idx, param := range f.Params {
+		names[idx] = param.Lexeme
+	}
+	return names
+}
 
-func (f LoxFunction) Call(i *Interpreter, args []interface{}) interface{} {
+func (f *LoxFunction) Call(i *Interpreter, args []Value) Value {
 	env := env.NewEnvironment(f.Env)
 
-	for idx, arg := range args {
-		env.Define(f.Params[idx].Lexeme, arg)
+	for idx, param := range f.Params {
+		if idx < len(args) && !isMissingArg(args[idx]) {
+			env.Define(param.Lexeme, args[idx])
+		} else {
+			// defaults are evaluated on every call, and can see the
+			// parameters before them
+			env.Define(param.Lexeme, i.evaluateIn(f.Defaults[idx], env))
+		}
+	}
+	if f.Rest != nil {
+		rest := make([]Value, 0)
+		if len(args) > len(f.Params) {
+			rest = append(rest, args[len(f.Params):]...)
+		}
+		env.Define(f.Rest.Lexeme, value.NewObject(NewLoxList(rest)))
 	}
 	i.ExecuteBlock(f.Body, env)
 
-	return nil
+	return value.Nil
 }
 
-func (f LoxFunction) String() string { return fmt.Sprintf("<fn %s>", f.Name.Lexeme) }
+func (f *LoxFunction) TypeName() string { return "function" }
+
+func (f *LoxFunction) String() string { return fmt.Sprintf("<fn %s>", f.Name.Lexeme) }
@@ -1,19 +1,49 @@
 package interpreter
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/vn-ki/go-lox/ast"
 	"github.com/vn-ki/go-lox/env"
 	"github.com/vn-ki/go-lox/token"
+	"github.com/vn-ki/go-lox/value"
 )
 
 type Interpreter struct {
 	ErrorHandler func(token token.Token, msg string)
 	env          *env.Environemnt
 	globals      *env.Environemnt
+
+	// path of the script or module being interpreted, empty in the REPL
+	path string
+	// the module being interpreted, nil for the main script
+	module *LoxModule
+	// imported modules by absolute path
+	modules map[string]*LoxModule
+	// the files being imported, to detect cycles
+	importStack []string
+	// globals defined by the embedder with RegisterNative and Bind, by name
+	bindings map[string]Value
+	// the source of random() and randomInt()
+	random *rand.Rand
+	// what scripts are allowed to do outside of the interpreter
+	capabilities Capability
+	// the script arguments returned by args()
+	args []string
+	// the reader of readLine(), created on the first call
+	stdin *bufio.Reader
+	// exits the process, replaced in tests
+	exit func(code int)
+	// the source of time for clock(), now() and sleep()
+	clock Clock
 }
 
 type runtimeError struct {
@@ -21,18 +51,84 @@ type runtimeError struct {
 	token token.Token
 }
 type returnError struct {
-	Value interface{}
+	Value Value
 }
 
 func NewInterpreter() *Interpreter {
 	globals := env.NewEnvironment(nil)
-	globals.Define("clock", FnClock{})
+	i := &Interpreter{
+		ErrorHandler: nil,
+		env:          globals,
+		globals:      globals,
+		modules:      make(map[string]*LoxModule),
+		bindings:     make(map[string]Value),
+		random:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		capabilities: CapAll,
+		exit:         os.Exit,
+		clock:        systemClock{},
+	}
+	i.defineNatives(globals)
 	globals.DumpEnv()
-	return &Interpreter{ErrorHandler: nil, env: globals, globals: globals}
+	return i
 }
 
-func (i *Interpreter) Evaluate(e ast.Expr) interface{} {
-	return e.Accept(i)
+// defineNatives defines the native functions, and the values bound by the
+// embedder, in a global environment
+func (i *Interpreter) defineNatives(globals *env.Environemnt) {
+	globals.Define("clock", value.NewObject(FnClock{}))
+	globals.Define("str", value.NewObject(FnStr{}))
+	i.defineMath(globals)
+	i.defineStrings(globals)
+	i.defineIO(globals)
+	i.defineJSON(globals)
+	i.defineTime(globals)
+	for name, value := range i.bindings {
+		globals.Define(name, value)
+	}
+}
+
+// Evaluate evaluates e. Expressions are dispatched with a type switch instead
+// of Accept, so that values aren't boxed into an interface{} on the way out.
+func (i *Interpreter) Evaluate(e ast.Expr) Value {
+	switch e := e.(type) {
+	case ast.Literal:
+		return i.VisitLiteral(e)
+	case ast.Evariable:
+		return i.VisitVariable(e)
+	case ast.Binary:
+		return i.VisitBinary(e)
+	case ast.Unary:
+		return i.VisitUnary(e)
+	case ast.Grouping:
+		return i.VisitGrouping(e)
+	case ast.Eassign:
+		return i.VisitAssign(e)
+	case ast.Elogical:
+		return i.VisitLogical(e)
+	case ast.Ecall:
+		return i.VisitCall(e)
+	case ast.Einterpolation:
+		return i.VisitInterpolation(e)
+	case ast.Eget:
+		return i.VisitGet(e)
+	case ast.Eset:
+		return i.VisitSet(e)
+	case ast.Econditional:
+		return i.VisitConditional(e)
+	case ast.Ecompound:
+		return i.VisitCompound(e)
+	case ast.Eincrement:
+		return i.VisitIncrement(e)
+	case ast.Elist:
+		return i.VisitList(e)
+	case ast.Eindex:
+		return i.VisitIndex(e)
+	case ast.EindexSet:
+		return i.VisitIndexSet(e)
+	case ast.Emap:
+		return i.VisitMap(e)
+	}
+	panic("Unreachable")
 }
 
 func (i *Interpreter) Interpret(stmts []ast.Stmt) {
@@ -44,6 +140,12 @@ func (i *Interpreter) Interpret(stmts []ast.Stmt) {
 				if i.ErrorHandler != nil {
 					i.ErrorHandler(re.token, re.Error())
 				}
+			} else if te, ok := r.(throwError); ok {
+				msg := "Uncaught exception: " + te.Value.String()
+				log.Printf("RuntimeError: at %d: %s\n", te.token.Line, msg)
+				if i.ErrorHandler != nil {
+					i.ErrorHandler(te.token, msg)
+				}
 			} else {
 				panic(r)
 			}
@@ -60,50 +162,193 @@ func (i *Interpreter) execute(s ast.Stmt) {
 }
 
 func (i *Interpreter) VisitExpression(s ast.Sexpression) interface{} {
-	return i.Evaluate(s.Expression)
+	i.Evaluate(s.Expression)
+	return nil
 }
 
-func (i *Interpreter) VisitCall(c ast.Ecall) (returnVal interface{}) {
+func (i *Interpreter) VisitCall(c ast.Ecall) Value {
 	callee := i.Evaluate(c.Callee)
-	args := make([]interface{}, 0)
+	args := make([]Value, 0, len(c.Args))
 	for _, arg := range c.Args {
 		args = append(args, i.Evaluate(arg))
 	}
-	if fun, ok := callee.(LoxCallable); ok {
-		if len(args) != fun.Arity() {
-			i.err("arity doesn't match", c.Paren)
+	if fun, ok := callee.AsObject().(LoxCallable); ok {
+		if len(c.Named) != 0 {
+			args = i.bindNamedArgs(fun, c, args)
+		}
+		if min, max := fun.Arity(); len(args) < min || (max >= 0 && len(args) > max) {
+			i.err(arityMessage(min, max, len(args)), c.Paren)
 		}
-		defer func() {
-			if r := recover(); r != nil {
-				if w, ok := r.(returnError); ok {
-					// log.Printf("return value: %v\n", w.Value)
-					// assign the value to the named return value
-					returnVal = w.Value
-					return
-				} else {
-					panic(r)
+		if _, ok := fun.(*LoxFunction); !ok {
+			// only lox functions have default values for skipped parameters
+			for idx, arg := range args {
+				if isMissingArg(arg) {
+					args[idx] = value.Nil
 				}
 			}
-		}()
-		fun.Call(i, args)
-		return
+		}
+		return i.call(fun, args, c.Paren)
 	}
-	i.err("not callable", c.Paren)
-	return nil
+	i.err(fmt.Sprintf("%s is not callable", callee.TypeName()), c.Paren)
+	return value.Nil
+}
+
+// call calls fun with args, which must already be checked against its arity.
+// Errors returned by natives are reported at tok.
+func (i *Interpreter) call(fun LoxCallable, args []Value, tok token.Token) (returnVal Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			if w, ok := r.(returnError); ok {
+				// log.Printf("return value: %v\n", w.Value)
+				// assign the value to the named return value
+				returnVal = w.Value
+				return
+			} else if w, ok := r.(nativeError); ok {
+				panic(runtimeError{w.error, tok})
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	return fun.Call(i, args)
+}
+
+// bindNamedArgs evaluates the named arguments of c and puts them after the
+// positional args, in the position of the parameter they name
+func (i *Interpreter) bindNamedArgs(fun LoxCallable, c ast.Ecall, args []Value) []Value {
+	namer, ok := fun.(ParamNamer)
+	if !ok {
+		i.err("function does not accept named arguments", c.Paren)
+	}
+	names := namer.ParamNames()
+	min, _ := fun.Arity()
+
+	positional := len(args)
+	for _, arg := range c.Named {
+		idx := -1
+		for paramIdx, name := range names {
+			if name == arg.Name.Lexeme {
+				idx = paramIdx
+			}
+		}
+		if idx < 0 {
+			i.err(fmt.Sprintf("unknown parameter '%s'", arg.Name.Lexeme), arg.Name)
+		}
+		if idx < positional {
+			i.err(fmt.Sprintf("parameter '%s' is already given by position", arg.Name.Lexeme), arg.Name)
+		}
+		for len(args) <= idx {
+			args = append(args, value.NewObject(missingArg{}))
+		}
+		args[idx] = i.Evaluate(arg.Value)
+	}
+
+	for idx, arg := range args {
+		if isMissingArg(arg) && idx < min {
+			i.err(fmt.Sprintf("missing argument for parameter '%s'", names[idx]), c.Paren)
+		}
+	}
+	return args
+}
+
+func arityMessage(min, max, got int) string {
+	switch {
+	case min == max:
+		return fmt.Sprintf("expected %d arguments but got %d", min, got)
+	case max < 0:
+		return fmt.Sprintf("expected at least %d arguments but got %d", min, got)
+	}
+	return fmt.Sprintf("expected %d to %d arguments but got %d", min, max, got)
 }
 
 func (i *Interpreter) VisitReturn(r ast.Sreturn) interface{} {
 	panic(returnError{i.Evaluate(r.Value)})
 }
 
+func (i *Interpreter) VisitThrow(s ast.Sthrow) interface{} {
+	panic(throwError{i.Evaluate(s.Value), s.Keyword})
+}
+
+func (i *Interpreter) VisitTry(s ast.Stry) interface{} {
+	if s.FinallyBody != nil {
+		// runs on the way out, even if the body or the catch clause panicked
+		defer i.ExecuteBlock(s.FinallyBody, env.NewEnvironment(i.env))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			val, ok := caught(r)
+			if !ok || s.CatchName == nil {
+				panic(r)
+			}
+			catchEnv := env.NewEnvironment(i.env)
+			catchEnv.Define(s.CatchName.Lexeme, val)
+			i.ExecuteBlock(s.CatchBody, catchEnv)
+		}
+	}()
+	i.ExecuteBlock(s.Body, env.NewEnvironment(i.env))
+	return nil
+}
+
+func (i *Interpreter) VisitMatch(s ast.Smatch) interface{} {
+	subject := i.Evaluate(s.Subject)
+	for _, c := range s.Cases {
+		for _, pattern := range c.Patterns {
+			caseEnv := env.NewEnvironment(i.env)
+			if i.matchPattern(pattern, subject, caseEnv) == nil {
+				i.ExecuteBlock([]ast.Stmt{c.Body}, caseEnv)
+				return nil
+			}
+		}
+	}
+	if s.Default != nil {
+		i.ExecuteBlock([]ast.Stmt{s.Default}, env.NewEnvironment(i.env))
+	}
+	return nil
+}
+
 func (i *Interpreter) VisitFunction(f ast.Sfunction) interface{} {
 	log.Printf("getting defined %s\n", f.Name.Lexeme)
-	i.env.Define(f.Name.Lexeme, NewLoxFunctionFromAst(f, i.env))
+	i.declare(f.Name, value.NewObject(NewLoxFunctionFromAst(f, i.env)), false)
 	i.env.DumpEnv()
 	return nil
 }
 
-func (i *Interpreter) VisitVariable(v ast.Evariable) interface{} {
+func (i *Interpreter) VisitGet(e ast.Eget) Value {
+	return i.propertyGet(e.Name, i.Evaluate(e.Object))
+}
+
+func (i *Interpreter) VisitSet(e ast.Eset) Value {
+	object := i.Evaluate(e.Object)
+	value := i.Evaluate(e.Value)
+	i.propertySet(e.Name, object, value)
+	return value
+}
+
+// propertyGet evaluates `object.name`
+func (i *Interpreter) propertyGet(name token.Token, object Value) Value {
+	if obj, ok := object.AsObject().(LoxObject); ok {
+		if val, ok := obj.Get(name); ok {
+			return val
+		}
+		i.err(fmt.Sprintf("undefined property '%s'", name.Lexeme), name)
+	}
+	i.err(fmt.Sprintf("%s has no properties", object.TypeName()), name)
+	return value.Nil
+}
+
+// propertySet evaluates `object.name = value`
+func (i *Interpreter) propertySet(name token.Token, object Value, val Value) {
+	obj, ok := object.AsObject().(LoxMutableObject)
+	if !ok {
+		i.err(fmt.Sprintf("cannot assign properties of %s", object.TypeName()), name)
+	}
+	if err := obj.Set(name, val); err != nil {
+		i.err(err.Error(), name)
+	}
+}
+
+func (i *Interpreter) VisitVariable(v ast.Evariable) Value {
 	val, ok := i.env.Get(v.Name.Lexeme)
 	if !ok {
 		panic(runtimeError{
@@ -115,17 +360,44 @@ func (i *Interpreter) VisitVariable(v ast.Evariable) interface{} {
 }
 
 func (i *Interpreter) VisitVar(v ast.Svar) interface{} {
-	var val interface{}
+	val := value.Nil
 	if v.Expression != nil {
 		val = i.Evaluate(v.Expression)
 	}
 	log.Printf("defining '%s' with '%v'", v.Name.Lexeme, val)
-	i.env.Define(v.Name.Lexeme, val)
+	i.declare(v.Name, val, v.Const)
+	return nil
+}
+
+// declare defines name in the current environment. Redeclaring a constant
+// of the same environment is an error, which the resolver can't always catch,
+// like when the constant was declared in an earlier REPL line or is a native.
+func (i *Interpreter) declare(name token.Token, val Value, constant bool) {
+	if i.env.IsConstant(name.Lexeme) {
+		i.err(fmt.Sprintf("cannot redeclare constant '%s'", name.Lexeme), name)
+	}
+	if constant {
+		i.env.DefineConst(name.Lexeme, val)
+	} else {
+		i.env.Define(name.Lexeme, val)
+	}
+}
+
+func (i *Interpreter) VisitDestructure(s ast.Sdestructure) interface{} {
+	val := i.Evaluate(s.Expression)
+	bindings := env.NewEnvironment(nil)
+	if err := i.matchPattern(s.Pattern, val, bindings); err != nil {
+		i.err("cannot destructure: "+err.Error(), s.Keyword)
+	}
+	for _, name := range ast.PatternBindings(s.Pattern) {
+		bound, _ := bindings.Get(name.Lexeme)
+		i.declare(name, bound, s.Const)
+	}
 	return nil
 }
 
 func (i *Interpreter) VisitWhile(s ast.Swhile) interface{} {
-	for i.isTruthy(i.Evaluate(s.Condition)) {
+	for i.Evaluate(s.Condition).Truthy() {
 		i.execute(s.Body)
 	}
 	return nil
@@ -133,7 +405,7 @@ func (i *Interpreter) VisitWhile(s ast.Swhile) interface{} {
 
 func (i *Interpreter) VisitIf(s ast.Sif) interface{} {
 	cond := i.Evaluate(s.Condition)
-	if i.isTruthy(cond) {
+	if cond.Truthy() {
 		i.execute(s.ThenBranch)
 	} else {
 		if s.ElseBranch != nil {
@@ -145,7 +417,7 @@ func (i *Interpreter) VisitIf(s ast.Sif) interface{} {
 
 func (i *Interpreter) VisitPrint(s ast.Sprint) interface{} {
 	val := i.Evaluate(s.Expression)
-	fmt.Println(val)
+	fmt.Println(val.String())
 	return nil
 }
 
@@ -164,124 +436,298 @@ func (i *Interpreter) ExecuteBlock(stmts []ast.Stmt, env *env.Environemnt) {
 	}
 }
 
-func (i *Interpreter) checkNumberOperand(op token.Token, operand interface{}) {
-	if _, ok := operand.(float64); !ok {
-		panic(runtimeError{errors.New("the operand should be a number"), op})
+// evaluateIn evaluates e in env instead of the current environment
+func (i *Interpreter) evaluateIn(e ast.Expr, env *env.Environemnt) Value {
+	prevEnv := i.env
+	defer func() { i.env = prevEnv }()
+	i.env = env
+
+	return i.Evaluate(e)
+}
+
+// checkNumberOperand checks that operand is a number and returns it
+func (i *Interpreter) checkNumberOperand(op token.Token, operand Value) float64 {
+	if !operand.IsNumber() {
+		i.err(fmt.Sprintf("operand of '%s' must be a number, got %s", op.Lexeme, operand.TypeName()), op)
+	}
+	return operand.AsNumber()
+}
+
+// checkNumberOperands checks that both operands are numbers and returns them
+func (i *Interpreter) checkNumberOperands(op token.Token, left Value, right Value) (float64, float64) {
+	if !left.IsNumber() || !right.IsNumber() {
+		i.err(fmt.Sprintf("operands of '%s' must be numbers, got %s and %s", op.Lexeme, left.TypeName(), right.TypeName()), op)
+	}
+	return left.AsNumber(), right.AsNumber()
+}
+
+// maxSafeInteger is the largest integer a float64 holds exactly
+const maxSafeInteger = 1 << 53
+
+// checkIntegerOperand checks that operand is a number with no fractional part
+// and returns it as an integer for the bitwise operators
+func (i *Interpreter) checkIntegerOperand(op token.Token, operand Value) int64 {
+	v := i.checkNumberOperand(op, operand)
+	if math.Trunc(v) != v || math.Abs(v) > maxSafeInteger {
+		i.err(fmt.Sprintf("operand of '%s' must be an integer, got %v", op.Lexeme, v), op)
+	}
+	return int64(v)
+}
+
+func (i *Interpreter) checkShiftCount(op token.Token, operand Value) uint {
+	count := i.checkIntegerOperand(op, operand)
+	if count < 0 || count > 63 {
+		panic(runtimeError{errors.New("shift count should be between 0 and 63"), op})
+	}
+	return uint(count)
+}
+
+func (i *Interpreter) checkNonZeroDivisor(op token.Token, divisor float64) {
+	if divisor == 0 {
+		panic(runtimeError{errors.New("division by zero"), op})
+	}
+}
+
+func (i *Interpreter) VisitAssign(e ast.Eassign) Value {
+	value := i.Evaluate(e.Value)
+	if err := i.env.Assign(e.Name.Lexeme, value); err != nil {
+		panic(runtimeError{err, e.Name})
+	}
+	// i.env.DumpEnv(0)
+	return value
+}
+
+// the binary operator each compound assignment applies
+var compoundOps = map[token.TokenType]token.TokenType{
+	token.TplusEqual:  token.Tplus,
+	token.TminusEqual: token.Tminus,
+	token.TstarEqual:  token.Tstar,
+	token.TslashEqual: token.Tslash,
+	token.TplusPlus:   token.Tplus,
+	token.TminusMinus: token.Tminus,
+}
+
+func (i *Interpreter) VisitCompound(e ast.Ecompound) Value {
+	get, set := i.reference(e.Target)
+	op := e.Op
+	op.Type = compoundOps[e.Op.Type]
+	value := i.binaryOp(op, get(), i.Evaluate(e.Value))
+	set(value)
+	return value
+}
+
+func (i *Interpreter) VisitIncrement(e ast.Eincrement) Value {
+	get, set := i.reference(e.Target)
+	old := get()
+	i.checkNumberOperand(e.Op, old)
+	op := e.Op
+	op.Type = compoundOps[e.Op.Type]
+	value := i.binaryOp(op, old, value.NewNumber(1))
+	set(value)
+	if e.Prefix {
+		return value
 	}
+	return old
 }
 
-func (i *Interpreter) checkNumberOperands(op token.Token, left interface{}, right interface{}) {
-	if _, ok := left.(float64); ok {
-		if _, ok := right.(float64); ok {
-			return
+// reference evaluates the sub-expressions of an assignment target once, and
+// returns functions to read and write the target
+func (i *Interpreter) reference(target ast.Expr) (get func() Value, set func(Value)) {
+	switch t := target.(type) {
+	case ast.Evariable:
+		get = func() Value { return i.VisitVariable(t) }
+		set = func(value Value) {
+			if err := i.env.Assign(t.Name.Lexeme, value); err != nil {
+				panic(runtimeError{err, t.Name})
+			}
 		}
+		return get, set
+	case ast.Eindex:
+		object := i.Evaluate(t.Object)
+		index := i.Evaluate(t.Index)
+		get = func() Value { return i.indexGet(t.Bracket, object, index) }
+		set = func(value Value) { i.indexSet(t.Bracket, object, index, value) }
+		return get, set
+	case ast.Eget:
+		object := i.Evaluate(t.Object)
+		get = func() Value { return i.propertyGet(t.Name, object) }
+		set = func(value Value) { i.propertySet(t.Name, object, value) }
+		return get, set
+	}
+	panic("Unreachable: parser only allows assignable targets")
+}
+
+func (i *Interpreter) VisitList(e ast.Elist) Value {
+	elements := make([]Value, len(e.Elements))
+	for idx, element := range e.Elements {
+		elements[idx] = i.Evaluate(element)
 	}
-	panic(runtimeError{errors.New("both operands should be number"), op})
+	return value.NewObject(NewLoxList(elements))
 }
 
-func (i *Interpreter) VisitAssign(e ast.Eassign) interface{} {
-	if i.env.Assign(e.Name.Lexeme, i.Evaluate(e.Value)) {
-		// i.env.DumpEnv(0)
-		return e.Value
+func (i *Interpreter) VisitMap(e ast.Emap) Value {
+	m := NewLoxMap()
+	for idx, key := range e.Keys {
+		k := i.Evaluate(key)
+		i.checkKey(e.Brace, k)
+		m.Set(k, i.Evaluate(e.Values[idx]))
 	}
-	panic(runtimeError{errors.New("Undefined variable"), e.Name})
+	return value.NewObject(m)
 }
 
-func (i *Interpreter) VisitLiteral(e ast.Literal) interface{} {
-	return e.Value
+func (i *Interpreter) VisitIndex(e ast.Eindex) Value {
+	object := i.Evaluate(e.Object)
+	return i.indexGet(e.Bracket, object, i.Evaluate(e.Index))
+}
+
+func (i *Interpreter) VisitIndexSet(e ast.EindexSet) Value {
+	object := i.Evaluate(e.Object)
+	index := i.Evaluate(e.Index)
+	value := i.Evaluate(e.Value)
+	i.indexSet(e.Bracket, object, index, value)
+	return value
+}
+
+func (i *Interpreter) VisitLiteral(e ast.Literal) Value {
+	return literalValue(e.Value)
+}
+
+// literalValue converts the value of a literal token or pattern
+func literalValue(literal interface{}) Value {
+	switch v := literal.(type) {
+	case nil:
+		return value.Nil
+	case bool:
+		return value.NewBool(v)
+	case float64:
+		return value.NewNumber(v)
+	case string:
+		return value.NewString(v)
+	}
+	panic("Unreachable: literals are nil, bools, numbers or strings")
 }
 
-func (i *Interpreter) VisitGrouping(e ast.Grouping) interface{} {
+func (i *Interpreter) VisitGrouping(e ast.Grouping) Value {
 	return i.Evaluate(e.Expression)
 }
 
-func (i *Interpreter) VisitUnary(e ast.Unary) interface{} {
+func (i *Interpreter) VisitUnary(e ast.Unary) Value {
 	right := i.Evaluate(e.Right)
 
 	switch e.Op.Type {
 	case token.Tminus:
-		i.checkNumberOperand(e.Op, right)
-		return -right.(float64)
+		return value.NewNumber(-i.checkNumberOperand(e.Op, right))
 	case token.Tbang:
-		return !i.isTruthy(right)
+		return value.NewBool(!right.Truthy())
+	case token.Ttilde:
+		return value.NewNumber(float64(^i.checkIntegerOperand(e.Op, right)))
 	}
 
 	panic("Unreachable")
 }
 
-func (i *Interpreter) VisitLogical(e ast.Elogical) interface{} {
+func (i *Interpreter) VisitInterpolation(e ast.Einterpolation) Value {
+	var b strings.Builder
+	for _, part := range e.Parts {
+		b.WriteString(i.Evaluate(part).String())
+	}
+	return value.NewString(b.String())
+}
+
+func (i *Interpreter) VisitConditional(e ast.Econditional) Value {
+	if i.Evaluate(e.Condition).Truthy() {
+		return i.Evaluate(e.ThenBranch)
+	}
+	return i.Evaluate(e.ElseBranch)
+}
+
+func (i *Interpreter) VisitLogical(e ast.Elogical) Value {
 	left := i.Evaluate(e.Left)
 	switch e.Op.Type {
 	case token.Tand:
-		if !i.isTruthy(left) {
+		if !left.Truthy() {
+			return left
+		}
+	case token.Tor:
+		if left.Truthy() {
 			return left
 		}
-	case token.Tfalse:
-		if i.isTruthy(left) {
+	case token.TquestionQuestion:
+		if !left.IsNil() {
 			return left
 		}
 	}
 	return i.Evaluate(e.Right)
 }
 
-func (i *Interpreter) VisitBinary(e ast.Binary) interface{} {
+func (i *Interpreter) VisitBinary(e ast.Binary) Value {
 	right := i.Evaluate(e.Right)
 	left := i.Evaluate(e.Left)
+	return i.binaryOp(e.Op, left, right)
+}
 
-	switch e.Op.Type {
+func (i *Interpreter) binaryOp(op token.Token, left, right Value) Value {
+	switch op.Type {
 	case token.Tminus:
-		i.checkNumberOperands(e.Op, left, right)
-		return left.(float64) - right.(float64)
+		l, r := i.checkNumberOperands(op, left, right)
+		return value.NewNumber(l - r)
 	case token.Tplus:
-		if l, ok := left.(string); ok {
-			if r, ok := right.(string); ok {
-				return l + r
-			}
-		} else if l, ok := left.(float64); ok {
-			if r, ok := right.(float64); ok {
-				return l + r
-			}
+		if left.IsNumber() && right.IsNumber() {
+			return value.NewNumber(left.AsNumber() + right.AsNumber())
+		} else if left.IsString() || right.IsString() {
+			// the other operand is converted like str() does
+			return value.NewString(left.String() + right.String())
 		}
-		panic(runtimeError{errors.New("Both operands must be either string or number"), e.Op})
+		i.err(fmt.Sprintf("operands of '+' must be two numbers or include a string, got %s and %s", left.TypeName(), right.TypeName()), op)
 	case token.Tstar:
-		i.checkNumberOperands(e.Op, left, right)
-		return left.(float64) * right.(float64)
+		l, r := i.checkNumberOperands(op, left, right)
+		return value.NewNumber(l * r)
 	case token.Tslash:
-		i.checkNumberOperands(e.Op, left, right)
-		return left.(float64) / right.(float64)
+		l, r := i.checkNumberOperands(op, left, right)
+		return value.NewNumber(l / r)
+	case token.Tpercent:
+		l, r := i.checkNumberOperands(op, left, right)
+		i.checkNonZeroDivisor(op, r)
+		return value.NewNumber(math.Mod(l, r))
+	case token.TtildeSlash:
+		l, r := i.checkNumberOperands(op, left, right)
+		i.checkNonZeroDivisor(op, r)
+		return value.NewNumber(math.Trunc(l / r))
+	case token.TstarStar:
+		l, r := i.checkNumberOperands(op, left, right)
+		return value.NewNumber(math.Pow(l, r))
+	case token.Tamp:
+		return value.NewNumber(float64(i.checkIntegerOperand(op, left) & i.checkIntegerOperand(op, right)))
+	case token.Tpipe:
+		return value.NewNumber(float64(i.checkIntegerOperand(op, left) | i.checkIntegerOperand(op, right)))
+	case token.Tcaret:
+		return value.NewNumber(float64(i.checkIntegerOperand(op, left) ^ i.checkIntegerOperand(op, right)))
+	case token.TlessLess:
+		l, r := i.checkIntegerOperand(op, left), i.checkShiftCount(op, right)
+		return value.NewNumber(float64(l << r))
+	case token.TgreaterGreater:
+		l, r := i.checkIntegerOperand(op, left), i.checkShiftCount(op, right)
+		return value.NewNumber(float64(l >> r))
 	case token.Tgreater:
-		i.checkNumberOperands(e.Op, left, right)
-		return left.(float64) > right.(float64)
+		l, r := i.checkNumberOperands(op, left, right)
+		return value.NewBool(l > r)
 	case token.TgreaterEqual:
-		i.checkNumberOperands(e.Op, left, right)
-		return left.(float64) >= right.(float64)
+		l, r := i.checkNumberOperands(op, left, right)
+		return value.NewBool(l >= r)
 	case token.Tless:
-		i.checkNumberOperands(e.Op, left, right)
-		return left.(float64) < right.(float64)
+		l, r := i.checkNumberOperands(op, left, right)
+		return value.NewBool(l < r)
 	case token.TlessEqual:
-		i.checkNumberOperands(e.Op, left, right)
-		return left.(float64) <= right.(float64)
+		l, r := i.checkNumberOperands(op, left, right)
+		return value.NewBool(l <= r)
 	case token.TequalEqual:
-		i.checkNumberOperands(e.Op, left, right)
-		// XXX: these arent same as book
-		return left == right
+		return value.NewBool(left.Equals(right))
 	case token.TbangEqual:
-		i.checkNumberOperands(e.Op, left, right)
-		return left != right
+		return value.NewBool(!left.Equals(right))
 	}
 	panic("All operators must be one of the above")
 }
 
-func (i *Interpreter) isTruthy(e interface{}) bool {
-	switch v := e.(type) {
-	case nil:
-		return false
-	case bool:
-		return v
-	}
-	return true
-}
-
 func (i *Interpreter) err(msg string, token token.Token) {
 	panic(runtimeError{errors.New(msg), token})
 }
@@ -1,11 +1,20 @@
 package interpreter
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/vn-ki/go-lox/ast"
 	"github.com/vn-ki/go-lox/lexer"
 	"github.com/vn-ki/go-lox/parser"
+	"github.com/vn-ki/go-lox/token"
+	"github.com/vn-ki/go-lox/value"
 )
 
 func parse(src string) ([]ast.Stmt, bool) {
@@ -60,3 +69,1246 @@ counter(5);
 	//
 	// }
 }
+
+func evaluate(t *testing.T, interp *Interpreter, src string) interface{} {
+	stmts, hadError := parse(src)
+	if hadError {
+		t.Fatalf("%s: parser error", src)
+	}
+	for _, stmt := range stmts[:len(stmts)-1] {
+		interp.execute(stmt)
+	}
+	return interp.Evaluate(stmts[len(stmts)-1].(ast.Sexpression).Expression).Interface()
+}
+
+// runtimeErrorMessage runs src in interp and returns the message of the
+// runtime error it throws, or "" if it doesn't throw one
+func runtimeErrorMessage(t *testing.T, interp *Interpreter, src string) string {
+	msg, _ := evaluate(t, interp, `var err = nil; try { `+src+` } catch (e) { err = e.message; } err;`).(string)
+	return msg
+}
+
+func TestInterpolation(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `var name = "lox"; "Hello ${name}! ${1 + 2} ${"nested ${name}"}";`)
+	expected := "Hello lox! 3 nested lox"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestTryCatchThrow(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	var caught = nil;
+	try {
+		throw "oops";
+		caught = "not thrown";
+	} catch (e) {
+		caught = e;
+	}
+	caught;`)
+	if got != "oops" {
+		t.Errorf("Expected: oops, got: %v", got)
+	}
+}
+
+func TestCatchRuntimeError(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	var msg = nil;
+	var line = nil;
+	try {
+		undefinedVariable;
+	} catch (e) {
+		msg = e.message;
+		line = e.line;
+	}
+	"${msg} at ${line}";`)
+	expected := "variable 'undefinedVariable' not defined at 5"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+
+	got = evaluate(t, interp, `
+	fun f(a) {}
+	var err = nil;
+	try { f(); } catch (e) { err = e; }
+	err.message;`)
+	if got != "expected 1 arguments but got 0" {
+		t.Errorf("Expected arity error, got: %v", got)
+	}
+}
+
+func TestFinally(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	var log = "";
+	fun f() {
+		try {
+			return "returned";
+		} finally {
+			log = log + "f";
+		}
+	}
+	var r = f();
+	try {
+		try {
+			throw "inner";
+		} catch (e) {
+			log = log + "c";
+			throw e + "!";
+		} finally {
+			log = log + "f";
+		}
+	} catch (e) {
+		log = log + e;
+	}
+	try {} finally { log = log + "f"; }
+	"${r} ${log}";`)
+	expected := "returned fcfinner!f"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestUncaughtThrow(t *testing.T) {
+	stmts, _ := parse(`throw "boom";`)
+	interp := NewInterpreter()
+	var got string
+	interp.ErrorHandler = func(tok token.Token, msg string) {
+		got = msg
+	}
+	interp.Interpret(stmts)
+	if got != "Uncaught exception: boom" {
+		t.Errorf("Expected uncaught exception, got: %s", got)
+	}
+}
+
+func TestArithmeticAndBitwiseOperators(t *testing.T) {
+	cases := map[string]float64{
+		"7 % 3;":         1,
+		"-7 % 3;":        -1,
+		"7.5 % 2;":       1.5,
+		"7 ~/ 2;":        3,
+		"-7 ~/ 2;":       -3,
+		"2 ** 10;":       1024,
+		"2 ** 3 ** 2;":   512,
+		"-2 ** 2;":       -4,
+		"2 ** -1;":       0.5,
+		"6 & 3;":         2,
+		"6 | 3;":         7,
+		"6 ^ 3;":         5,
+		"~5;":            -6,
+		"1 << 4;":        16,
+		"-16 >> 2;":      -4,
+		"1 + 2 << 1;":    6,
+		"1 | 2 ^ 3 & 1;": 3,
+		"2 * 3 % 4;":     2,
+	}
+	interp := NewInterpreter()
+	for src, expected := range cases {
+		if got := evaluate(t, interp, src); got != expected {
+			t.Errorf("%s expected: %v, got: %v", src, expected, got)
+		}
+	}
+}
+
+func TestOperatorTypeErrors(t *testing.T) {
+	for _, src := range []string{
+		`1.5 & 1;`, `"a" | 1;`, `~true;`, `1 << 64;`, `1 >> -1;`, `1 % 0;`, `1 ~/ 0;`, `"a" ** 2;`,
+	} {
+		got := evaluate(t, NewInterpreter(), `var err = nil; try { `+src+` } catch (e) { err = e; } err;`)
+		if _, ok := got.(LoxError); !ok {
+			t.Errorf("%s: expected a runtime error, got: %v", src, got)
+		}
+	}
+}
+
+func TestConditionalAndCoalesce(t *testing.T) {
+	cases := map[string]interface{}{
+		`true ? 1 : 2;`:                  1.0,
+		`nil ? 1 : 2;`:                   2.0,
+		`false ? 1 : true ? 2 : 3;`:      2.0,
+		`nil ?? "default";`:              "default",
+		`false ?? "default";`:            false,
+		`nil ?? nil ?? 3;`:               3.0,
+		`nil ?? false ? "yes" : "no";`:   "no",
+		`1 ?? undefinedVariable;`:        1.0,
+		`true ? 1 : undefinedVariable;`:  1.0,
+		`false ? undefinedVariable : 2;`: 2.0,
+		`true or undefinedVariable;`:     true,
+	}
+	interp := NewInterpreter()
+	for src, expected := range cases {
+		if got := evaluate(t, interp, src); got != expected {
+			t.Errorf("%s expected: %v, got: %v", src, expected, got)
+		}
+	}
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	var a = 10;
+	a += 5;
+	a -= 3;
+	a *= 2;
+	a /= 4;
+	var s = "a";
+	s += "b";
+	"${a} ${s} ${a += 1}";`)
+	expected := "6 ab 7"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestIncrementDecrement(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	var i = 0;
+	var a = i++;
+	var b = ++i;
+	var c = i--;
+	var d = --i;
+	"${a} ${b} ${c} ${d} ${i}";`)
+	expected := "0 2 2 0 0"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+
+	got = evaluate(t, interp, `var err = nil; var s = "a"; try { s++; } catch (e) { err = e; } err;`)
+	if _, ok := got.(LoxError); !ok {
+		t.Errorf("Expected a runtime error, got: %v", got)
+	}
+}
+
+func resolve(src string) []string {
+	stmts, _ := parse(src)
+	errs := make([]string, 0)
+	resolver := NewResolver()
+	resolver.ErrorHandler = func(tok token.Token, msg string) {
+		errs = append(errs, msg)
+	}
+	resolver.Resolve(stmts)
+	return errs
+}
+
+func TestResolverConst(t *testing.T) {
+	cases := map[string]int{
+		`const PI = 3.14; PI = 3;`:                        1,
+		`const PI = 3.14; PI += 1;`:                       1,
+		`const PI = 3.14; PI++;`:                          1,
+		`const PI = 3.14; fun f() { PI = 1; }`:            1,
+		`const PI = 3.14; { var PI = 1; PI = 2; }`:        0,
+		`{ const A = 1; { A = 2; } }`:                     1,
+		`var a = 1; a = 2; const B = a;`:                  0,
+		`const C = 1; var C = 2;`:                         1,
+		`fun f() { X = 2; } const X = 1;`:                 0,
+		`for (var i = 0; i < 3; i++) { const j = i; }`:    0,
+		`try {} catch (e) { e = 1; } const e = 1; e = 2;`: 1,
+	}
+	for src, expected := range cases {
+		if errs := resolve(src); len(errs) != expected {
+			t.Errorf("%s: expected %d errors, got: %v", src, expected, errs)
+		}
+	}
+}
+
+func TestResolverReturn(t *testing.T) {
+	cases := map[string]int{
+		`return nil;`:                                 1,
+		`if (true) { return 1; }`:                     1,
+		`fun f() { return 1; }`:                       0,
+		`fun f() { fun g() { return 1; } return g; }`: 0,
+		`fun f() {} return f;`:                        1,
+	}
+	for src, expected := range cases {
+		if errs := resolve(src); len(errs) != expected {
+			t.Errorf("%s: expected %d errors, got: %v", src, expected, errs)
+		}
+	}
+}
+
+func TestConstRuntime(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	const RATE = 3.14;
+	fun f() { RATE = 1; }
+	var err = nil;
+	try { f(); } catch (e) { err = e.message; }
+	"${RATE} ${err}";`)
+	expected := "3.14 cannot assign to constant 'RATE'"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestConstRedeclare(t *testing.T) {
+	// every REPL line is resolved on its own, so only the runtime sees the
+	// constant of an earlier line
+	interp := NewInterpreter()
+	if _, err := interp.EvalString(`const PI2 = 1;`); err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []string{`var PI2 = 2;`, `fun PI2() {}`, `var [PI2] = [2];`} {
+		if _, err := interp.EvalString(src); err == nil || !strings.Contains(err.Error(), "cannot redeclare constant 'PI2'") {
+			t.Errorf("%s: expected a redeclare error, got: %v", src, err)
+		}
+	}
+	if got, err := interp.EvalString(`PI2`); err != nil || got.Interface() != float64(1) {
+		t.Errorf("Expected PI2 to still be 1, got: %v (%v)", got, err)
+	}
+
+	// PI and E are constants defined by the interpreter
+	for src, expected := range map[string]string{
+		`var PI = 3;`:                  "cannot redeclare constant 'PI'",
+		`const E = 3;`:                 "cannot redeclare constant 'E'",
+		`{ var PI = 3; PI = 4; }`:      "",
+		`fun f(E) { return E; } f(1);`: "",
+	} {
+		_, err := NewInterpreter().EvalString(src)
+		if (expected == "" && err != nil) || (expected != "" && (err == nil || !strings.Contains(err.Error(), expected))) {
+			t.Errorf("%s expected: %q, got: %v", src, expected, err)
+		}
+	}
+}
+
+func TestDefaultAndRestParameters(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	var calls = 0;
+	fun next() { calls++; return calls; }
+	fun f(a, b = a * 2, c = next(), ...rest) {
+		return "${a} ${b} ${c} ${rest}";
+	}
+	[f(1), f(1, 5), f(1, 5, 6), f(1, 2, 3, 4, 5), calls];`)
+	expected := "[1 2 1 [], 1 5 2 [], 1 5 6 [], 1 2 3 [4, 5], 2]"
+	if got.(*LoxList).String() != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestArityErrors(t *testing.T) {
+	cases := map[string]string{
+		`fun f(a, b = 1) {} f();`:        "expected 1 to 2 arguments but got 0",
+		`fun f(a, b = 1) {} f(1, 2, 3);`: "expected 1 to 2 arguments but got 3",
+		`fun f(a, ...r) {} f();`:         "expected at least 1 arguments but got 0",
+		`clock(1);`:                      "expected 0 arguments but got 1",
+	}
+	for src, expected := range cases {
+		got := runtimeErrorMessage(t, NewInterpreter(), src)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	cases := map[string]string{
+		`-"a";`:        "operand of '-' must be a number, got string",
+		`1 + nil;`:     "operands of '+' must be two numbers or include a string, got number and nil",
+		`"a" * 2;`:     "operands of '*' must be numbers, got string and number",
+		`1.5 & 1;`:     "operand of '&' must be an integer, got 1.5",
+		`true < [];`:   "operands of '<' must be numbers, got bool and list",
+		`nil();`:       "nil is not callable",
+		`1[0];`:        "number can't be indexed",
+		`[1]["a"];`:    "list index must be an integer, got a",
+		`"a".length;`:  "string has no properties",
+		`clock.x = 1;`: "cannot assign properties of function",
+	}
+	for src, expected := range cases {
+		got := runtimeErrorMessage(t, NewInterpreter(), src)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+}
+
+func TestEquality(t *testing.T) {
+	cases := map[string]bool{
+		`nil == nil`:       true,
+		`nil == false`:     false,
+		`true == true`:     true,
+		`true != false`:    true,
+		`1 == 1.0`:         true,
+		`1 == "1"`:         false,
+		`0 == false`:       false,
+		`"" == nil`:        false,
+		`"a" == "a"`:       true,
+		`"a" != "b"`:       true,
+		`-0 == 0`:          true,
+		`0 / 0 == 0 / 0`:   false,
+		`0 / 0 != 0 / 0`:   true,
+		`f == f`:           true,
+		`f == g`:           false,
+		`f == "<fn f>"`:    false,
+		`make() == make()`: false,
+		`clock == clock`:   true,
+		`xs == xs`:         true,
+		`[1] == [1]`:       false,
+		`({}) != {}`:       true,
+		`m[-0] == "zero"`:  true,
+		`m["1"] == nil`:    true,
+		`test(nil) == "nil" and test("s") == "string" and test(1) == "number"`: true,
+		`test(false) == nil and test("1") == nil`:                              true,
+	}
+	for src, expected := range cases {
+		got := evaluate(t, NewInterpreter(), `
+		fun f() {} fun g() {}
+		fun make() { fun inner() {} return inner; }
+		var xs = [1];
+		var m = {0: "zero", 1: "one"};
+		fun test(x) {
+			match (x) {
+				case nil => return "nil";
+				case "s" => return "string";
+				case 1 => return "number";
+			}
+		}
+		`+src+`;`)
+		if got != expected {
+			t.Errorf("%s expected: %v, got: %v", src, expected, got)
+		}
+	}
+}
+
+func TestStringify(t *testing.T) {
+	cases := map[string]string{
+		`str(nil)`:              "nil",
+		`str(true)`:             "true",
+		`str(1000000)`:          "1000000",
+		`str(2.5)`:              "2.5",
+		`str(-0.125)`:           "-0.125",
+		`str(1e21)`:             "1e+21",
+		`str(1e-9)`:             "1e-09",
+		`str(0 / 0)`:            "NaN",
+		`str(-1 / 0)`:           "-Infinity",
+		`str("s")`:              "s",
+		`str(f)`:                "<fn f>",
+		`str(clock)`:            "<native fn>",
+		`str([1, nil, [2.0]])`:  "[1, nil, [2]]",
+		`str({a: 1e6})`:         "{a: 1000000}",
+		`"n: " + 3`:             "n: 3",
+		`nil + "!"`:             "nil!",
+		`"${1.0} ${f}"`:         "1 <fn f>",
+		`"${[true]}" + [false]`: "[true][false]",
+	}
+	for src, expected := range cases {
+		got := evaluate(t, NewInterpreter(), `fun f() {} `+src+`;`)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+}
+
+func TestStringifyCycles(t *testing.T) {
+	cases := map[string]string{
+		`var a = [1]; a[0] = a; str(a)`:                      "[[...]]",
+		`var m = {}; m["self"] = m; "${m}"`:                  "{self: {...}}",
+		`var a = [1]; var m = {a: a}; a[0] = m; "" + a`:      "[{a: [...]}]",
+		`var b = [2]; str([b, b])`:                           "[[2], [2]]",
+		`var a = [0]; var m = {a: a}; a[0] = m; str([m, m])`: "[{a: [{...}]}, {a: [{...}]}]",
+	}
+	for src, expected := range cases {
+		got := evaluate(t, NewInterpreter(), src+`;`)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+}
+
+func TestListIndexing(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	var calls = 0;
+	fun idx() { calls++; return 1; }
+	var xs = [1, [2, 3], "four",];
+	xs[0] = xs[1][0] + 10;
+	xs[idx()][1] += 5;
+	xs[idx()][0]++;
+	"${xs} ${calls}";`)
+	expected := "[12, [3, 8], four] 2"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+
+	for _, src := range []string{`[1][1];`, `[1][-1];`, `[1][1e300];`, `[1][0.5];`, `"a"[0];`, `[1]["a"] = 2;`} {
+		got := evaluate(t, NewInterpreter(), `var err = nil; try { `+src+` } catch (e) { err = e; } err;`)
+		if _, ok := got.(LoxError); !ok {
+			t.Errorf("%s: expected a runtime error, got: %v", src, got)
+		}
+	}
+}
+
+func TestNativeReturnValue(t *testing.T) {
+	if got := evaluate(t, NewInterpreter(), `clock();`); got == nil {
+		t.Errorf("Expected clock() to return a value")
+	}
+}
+
+func TestNamedArguments(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	fun connect(host, port = 80, secure = false, ...rest) {
+		return "${host}:${port} ${secure} ${rest}";
+	}
+	[
+		connect(host: "a"),
+		connect("b", secure: true),
+		connect(port: 8080, host: "c"),
+		connect("d", 1, secure: true),
+	];`)
+	expected := "[a:80 false [], b:80 true [], c:8080 false [], d:1 true []]"
+	if got.(*LoxList).String() != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestNamedArgumentErrors(t *testing.T) {
+	cases := map[string]string{
+		`f(1, a: 2);`:    "parameter 'a' is already given by position",
+		`f(c: 2);`:       "unknown parameter 'c'",
+		`f(b: 2);`:       "missing argument for parameter 'a'",
+		`f(rest: 2);`:    "unknown parameter 'rest'",
+		`clock(a: 1);`:   "function does not accept named arguments",
+		`f(1, b: 2, 3);`: "",
+	}
+	for src, expected := range cases {
+		if expected == "" {
+			if _, hadError := parse(src); !hadError {
+				t.Errorf("%s: expected a parser error", src)
+			}
+			continue
+		}
+		got := runtimeErrorMessage(t, NewInterpreter(), `fun f(a, b = 1, ...rest) {} `+src)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+}
+
+type fnGreet struct{}
+
+func (f fnGreet) Arity() (int, int)    { return 1, 2 }
+func (f fnGreet) ParamNames() []string { return []string{"name", "greeting"} }
+func (f fnGreet) TypeName() string     { return "function" }
+func (f fnGreet) Call(_ *Interpreter, args []Value) Value {
+	if len(args) < 2 || args[1].IsNil() {
+		return value.NewString("Hello " + args[0].AsString())
+	}
+	return value.NewString(args[1].AsString() + " " + args[0].AsString())
+}
+
+func TestNativeNamedArguments(t *testing.T) {
+	interp := NewInterpreter()
+	interp.globals.Define("greet", value.NewObject(fnGreet{}))
+	got := evaluate(t, interp, `[greet(greeting: "Hi", name: "a"), greet(name: "b")];`)
+	expected := "[Hi a, Hello b]"
+	if got.(*LoxList).String() != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestMaps(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	var key = "k";
+	var m = {name: "lox", "a b": 1, 2: true, key: 0};
+	m[key] = 3;
+	m["a b"] += 1;
+	m;`)
+	if _, ok := got.(*LoxMap); !ok {
+		t.Fatalf("Expected a map, got: %v", got)
+	}
+	got = evaluate(t, interp, `"${m} ${m["missing"] ?? "none"} ${m[2]}";`)
+	expected := "{name: lox, a b: 2, 2: true, key: 0, k: 3} none true"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	fun describe(x) {
+		var ret = nil;
+		match (x) {
+			case 1, 2 => ret = "small";
+			case -1 => ret = "negative";
+			case "x" => ret = "x";
+			case nil => ret = "nil";
+			case [] => ret = "empty";
+			case [a, [b, _]] => ret = "nested ${a} ${b}";
+			case [first, ...rest] => ret = "first ${first} rest ${rest}";
+			case {type: "point", x, y: yy} => ret = "point ${x} ${yy}";
+			case {name: n} => { var greeting = "hi"; ret = "${greeting} ${n}"; }
+			default => ret = "other";
+		}
+		return ret;
+	}
+	[
+		describe(1), describe(2), describe(-1), describe("x"), describe(nil),
+		describe([]), describe([1, [2, 3]]), describe([1, 2, 3]),
+		describe({type: "point", x: 1, y: 2}), describe({name: "lox", extra: 1}),
+		describe({type: "line"}), describe(3), describe(true),
+	];`)
+	expected := "[small, small, negative, x, nil, empty, nested 1 2, first 1 rest [2, 3], " +
+		"point 1 2, hi lox, other, other, other]"
+	if got.(*LoxList).String() != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestMatchBindingsAreScoped(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	var a = "outer";
+	match ([1]) {
+		case [a] => a = a + 1;
+	}
+	match (5) {
+		case 1 => a = "no match";
+	}
+	a;`)
+	if got != "outer" {
+		t.Errorf("Expected: outer, got: %v", got)
+	}
+}
+
+func TestMapKeyErrors(t *testing.T) {
+	for _, src := range []string{`var m = {[1]: 1};`, `var m = {}; m[{}] = 1;`, `({})[0 / 0];`} {
+		got := evaluate(t, NewInterpreter(), `var err = nil; try { `+src+` } catch (e) { err = e; } err;`)
+		if _, ok := got.(LoxError); !ok {
+			t.Errorf("%s: expected a runtime error, got: %v", src, got)
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	interp := NewInterpreter()
+	got := evaluate(t, interp, `
+	fun pair() { return [1, 2]; }
+	var [a, b] = pair();
+	var [first, [second, _], ...rest] = [1, [2, 3], 4, 5];
+	var {name, age: years} = {name: "lox", age: 25, extra: true};
+	const [c] = [3];
+	"${a} ${b} ${first} ${second} ${rest} ${name} ${years} ${c}";`)
+	expected := "1 2 1 2 [4, 5] lox 25 3"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	cases := map[string]string{
+		`var [a, b] = [1];`:       "cannot destructure: expected a list of 2 elements but got 1",
+		`var [a, ...b] = [];`:     "cannot destructure: expected a list of at least 1 elements but got 0",
+		`var [a] = "a";`:          "cannot destructure: expected a list but got a",
+		`var {name} = {};`:        "cannot destructure: missing key name",
+		`var {name} = [1];`:       "cannot destructure: expected a map but got [1]",
+		`const [x] = [1]; x = 2;`: "cannot assign to constant 'x'",
+	}
+	for src, expected := range cases {
+		got := runtimeErrorMessage(t, NewInterpreter(), src)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+	if errs := resolve(`const [a, {b}] = x; b = 1;`); len(errs) != 1 {
+		t.Errorf("Expected one resolver error, got: %v", errs)
+	}
+}
+
+func writeModules(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "lox-modules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestImport(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib/strings.lox": `
+		import "counter.lox" as counter;
+		var private = "!";
+		export fun shout(s) { return s + private; }
+		export const name = "strings";
+		export var [count] = [counter.increment()];`,
+		"lib/counter.lox": `
+		var n = 0;
+		export fun increment() { n++; return n; }`,
+		"other.lox": `import "lib/counter.lox" as counter; export var count = counter.increment();`,
+	})
+	defer os.RemoveAll(dir)
+
+	interp := NewInterpreter()
+	interp.SetScriptPath(filepath.Join(dir, "main.lox"))
+	got := evaluate(t, interp, `
+	import "lib/strings.lox" as strings;
+	import "other.lox" as other;
+	import "lib/strings.lox" as again;
+	var err = nil;
+	try { strings.private; } catch (e) { err = e.message; }
+	"${strings.shout("hi")} ${strings.name} ${strings.count} ${other.count} ${again.count} ${strings} ${err}";`)
+	expected := "hi! strings 1 2 1 <module strings> undefined property 'private'"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %v", expected, got)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"a.lox":      `import "b.lox" as b;`,
+		"b.lox":      `import "a.lox" as a;`,
+		"self.lox":   `import "main.lox" as main;`,
+		"broken.lox": `var = ;`,
+	})
+	defer os.RemoveAll(dir)
+
+	cases := map[string]string{
+		`import "a.lox" as a;`:       "import cycle: a.lox -> b.lox -> a.lox",
+		`import "self.lox" as s;`:    "import cycle: main.lox -> self.lox -> main.lox",
+		`import "missing.lox" as m;`: "cannot import",
+		`import "broken.lox" as m;`:  "in " + filepath.Join(dir, "broken.lox"),
+	}
+	for src, expected := range cases {
+		interp := NewInterpreter()
+		interp.SetScriptPath(filepath.Join(dir, "main.lox"))
+		got := runtimeErrorMessage(t, interp, src)
+		if !strings.HasPrefix(got, expected) {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+
+	if errs := resolve(`{ export var a = 1; }`); len(errs) != 1 {
+		t.Errorf("Expected one resolver error, got: %v", errs)
+	}
+}
+
+func TestRegisterNative(t *testing.T) {
+	interp := NewInterpreter()
+	interp.RegisterNative("double", 1, func(args []Value) (Value, error) {
+		if !args[0].IsNumber() {
+			return value.Nil, errors.New("double: expected a number")
+		}
+		return value.NewNumber(args[0].AsNumber() * 2), nil
+	})
+	interp.RegisterNative("count", -1, func(args []Value) (Value, error) {
+		return value.NewNumber(float64(len(args))), nil
+	})
+
+	cases := map[string]interface{}{
+		`double(21)`:                float64(42),
+		`count() + count(1, 2, 3);`: float64(3),
+		`"${double}"`:               "<native fn>",
+		`var e = nil; try { double("x"); } catch (err) { e = err.message; } e`: "double: expected a number",
+		`var e = nil; try { double(); } catch (err) { e = err.message; } e`:    "expected 1 arguments but got 0",
+	}
+	for src, expected := range cases {
+		got, err := interp.EvalString(src)
+		if err != nil || got.Interface() != expected {
+			t.Errorf("%s expected: %v, got: %v (%v)", src, expected, got, err)
+		}
+	}
+
+	_, err := interp.EvalString("var x = 1;\ndouble(nil);")
+	if re, ok := err.(*RuntimeError); !ok || re.Line != 2 || re.Message != "double: expected a number" {
+		t.Errorf("Expected a runtime error on line 2, got: %v", err)
+	}
+
+	dir := writeModules(t, map[string]string{"lib.lox": `export var x = double(2);`})
+	defer os.RemoveAll(dir)
+	interp.SetScriptPath(filepath.Join(dir, "main.lox"))
+	if got, err := interp.EvalString(`import "lib.lox" as lib; lib.x`); err != nil || got.Interface() != float64(4) {
+		t.Errorf("Expected natives in modules, got: %v (%v)", got, err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	interp := NewInterpreter()
+	_, err := interp.EvalString(`
+	fun discount(total, rate = 0.1) { return total * rate; }
+	fun total(order) { return order["price"] * order["qty"] + order["fees"][1]; }
+	fun fail(code) { throw code; }
+	var notAFunction = 1;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := interp.Call("discount", 200); err != nil || got.Interface() != float64(20) {
+		t.Errorf("Expected 20, got: %v (%v)", got, err)
+	}
+	if got, err := interp.Call("discount", 200, float32(0.5)); err != nil || got.Interface() != float64(100) {
+		t.Errorf("Expected 100, got: %v (%v)", got, err)
+	}
+	order := map[string]interface{}{"price": 2.5, "qty": 4, "fees": []float64{1, 2}}
+	if got, err := interp.Call("total", order); err != nil || got.Interface() != float64(12) {
+		t.Errorf("Expected 12, got: %v (%v)", got, err)
+	}
+
+	_, err = interp.Call("fail", 42)
+	if re, ok := err.(*RuntimeError); !ok || re.Value.Interface() != float64(42) {
+		t.Errorf("Expected an uncaught exception with value 42, got: %v", err)
+	}
+	for _, err := range []error{
+		func() error { _, err := interp.Call("missing"); return err }(),
+		func() error { _, err := interp.Call("notAFunction"); return err }(),
+		func() error { _, err := interp.Call("discount"); return err }(),
+		func() error { _, err := interp.Call("discount", struct{}{}); return err }(),
+		func() error { _, err := interp.Call("total", map[string]interface{}{}); return err }(),
+	} {
+		if err == nil {
+			t.Errorf("Expected an error")
+		}
+	}
+}
+
+func TestEvalString(t *testing.T) {
+	interp := NewInterpreter()
+	if _, err := interp.EvalString(`var price = 150;`); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		src      string
+		expected interface{}
+	}{
+		{`price > 100`, true},
+		{`price * 2;`, float64(300)},
+		{`price = price + 1;`, float64(151)},
+		{`print price;`, nil},
+	}
+	for _, c := range cases {
+		if got, err := interp.EvalString(c.src); err != nil || got.Interface() != c.expected {
+			t.Errorf("%s expected: %v, got: %v (%v)", c.src, c.expected, got, err)
+		}
+	}
+	if _, err := interp.EvalString(`var = ;`); err == nil {
+		t.Errorf("Expected a parser error")
+	}
+	if _, err := interp.EvalString(`const c = 1; c = 2;`); err == nil {
+		t.Errorf("Expected a resolver error")
+	}
+	for _, src := range []string{`return 1;`, `{ return nil; }`} {
+		if _, err := interp.EvalString(src); err == nil {
+			t.Errorf("%s: expected a resolver error", src)
+		}
+	}
+}
+
+type testAccount struct {
+	Owner   string
+	Balance float64
+	Tags    []string
+	History map[string]int
+	Address testAddress
+	Serial  int64
+	Visits  uint8
+	limit   int
+}
+
+type testAddress struct {
+	City string
+}
+
+func (a *testAccount) Deposit(amount float64) error {
+	if amount <= 0 {
+		return errors.New("deposit must be positive")
+	}
+	a.Balance += amount
+	return nil
+}
+
+func (a *testAccount) Summary() (string, float64) { return a.Owner, a.Balance }
+
+func TestBindGoValues(t *testing.T) {
+	interp := NewInterpreter()
+	acct := &testAccount{Owner: "alice", Balance: 100, History: map[string]int{"b": 2, "a": 1}}
+	binds := map[string]interface{}{
+		"acct": acct,
+		"sum": func(xs ...int) int {
+			total := 0
+			for _, x := range xs {
+				total += x
+			}
+			return total
+		},
+		"keys": func(m map[string]interface{}) []string {
+			keys := make([]string, 0)
+			for key := range m {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			return keys
+		},
+		"newAccount": func(owner string) *testAccount { return &testAccount{Owner: owner} },
+		"owner":      func(a *testAccount) string { return a.Owner },
+	}
+	for name, v := range binds {
+		if err := interp.Bind(name, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := interp.Bind("ch", make(chan int)); err == nil {
+		t.Errorf("Expected an error binding a channel")
+	}
+
+	// the cases share acct, so they run in order
+	cases := []struct {
+		src      string
+		expected interface{}
+	}{
+		{`acct.balance += 10; acct.deposit(5); acct.balance`, float64(115)},
+		{`acct.owner = "bob"; acct.tags = ["x", "y"]; acct.Owner`, "bob"},
+		{`var [name, balance] = acct.summary(); "${name} ${balance}"`, "bob 115"},
+		{`"${acct.history}"`, "{a: 1, b: 2}"},
+		{`sum() + sum(1, 2, 3)`, float64(6)},
+		{`"${keys({b: 1, a: [1]})}"`, "[a, b]"},
+		{`var c = newAccount("carol"); owner(c)`, "carol"},
+		{`"${acct} ${sum}"`, "<testAccount instance> <native fn>"},
+		{`acct.nope`, "undefined property 'nope'"},
+		{`acct.limit`, "undefined property 'limit'"},
+		{`acct.deposit(-1)`, "deposit must be positive"},
+		{`acct.balance = "x"`, "cannot set balance: cannot convert x to float64"},
+		{`sum(1.5)`, "sum: argument 1: 1.5 is out of range for int"},
+		{`owner(1)`, "owner: argument 1: cannot convert 1 to *interpreter.testAccount"},
+		{`keys({1: 2})`, "keys: argument 1: key 1: cannot convert 1 to string"},
+		{`acct.tags = [1]`, "cannot set tags: element 0: cannot convert 1 to string"},
+		{`acct.address.city = "paris"; acct.address.city`, "paris"},
+		{`acct.serial = 9007199254740992; acct.serial`, float64(9007199254740992)},
+		{`acct.serial = 1e300`, "cannot set serial: 1e+300 is out of range for int64"},
+		{`acct.serial = 1e19`, "cannot set serial: 1e+19 is out of range for int64"},
+		{`acct.serial = -1e19`, "cannot set serial: -1e+19 is out of range for int64"},
+		{`acct.visits = 255; acct.visits`, float64(255)},
+		{`acct.visits = 256`, "cannot set visits: 256 is out of range for uint8"},
+		{`acct.visits = 1e300`, "cannot set visits: 1e+300 is out of range for uint8"},
+	}
+	for _, c := range cases {
+		val, err := interp.EvalString(c.src)
+		got := val.Interface()
+		if err != nil {
+			got = err.(*RuntimeError).Message
+		}
+		if got != c.expected {
+			t.Errorf("%s expected: %v, got: %v", c.src, c.expected, got)
+		}
+	}
+	if acct.Owner != "bob" || acct.Balance != 115 || len(acct.Tags) != 2 || acct.Address.City != "paris" ||
+		acct.Serial != 9007199254740992 || acct.Visits != 255 {
+		t.Errorf("Expected the Go struct to be updated, got: %+v", acct)
+	}
+}
+
+func TestMath(t *testing.T) {
+	cases := map[string]interface{}{
+		`sqrt(16)`:                           float64(4),
+		`pow(2, 10)`:                         float64(1024),
+		`abs(-2.5)`:                          2.5,
+		`floor(-1.5) + ceil(1.2)`:            float64(0),
+		`round(2.5) + round(-2.5)`:           float64(0),
+		`min(3, 1, 2) + max(3, 7)`:           float64(8),
+		`sin(0) + cos(0) + tan(0)`:           float64(1),
+		`log(E) + exp(0)`:                    float64(2),
+		`floor(PI * 100)`:                    float64(314),
+		`str(sqrt(-1))`:                      "NaN",
+		`var r = random(); r >= 0 and r < 1`: true,
+		`var n = randomInt(1, 3); n >= 1 and n <= 3 and n == floor(n)`: true,
+		`randomInt(5, 5)`: float64(5),
+	}
+	for src, expected := range cases {
+		got := evaluate(t, NewInterpreter(), src+`;`)
+		if got != expected {
+			t.Errorf("%s expected: %v, got: %v", src, expected, got)
+		}
+	}
+
+	errors := map[string]string{
+		`sqrt("4");`:         "sqrt: argument 1 must be a number, got string",
+		`pow(2, nil);`:       "pow: argument 2 must be a number, got nil",
+		`max();`:             "max: expected at least 1 arguments but got 0",
+		`min(1, "2");`:       "min: argument 2 must be a number, got string",
+		`randomInt(1.5, 2);`: "randomInt: argument 1 must be an integer, got 1.5",
+		`randomInt(3, 1);`:   "randomInt: min 3 is greater than max 1",
+		`PI = 3;`:            "cannot assign to constant 'PI'",
+	}
+	for src, expected := range errors {
+		got := runtimeErrorMessage(t, NewInterpreter(), src)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+}
+
+func TestRandomSeed(t *testing.T) {
+	src := `str([random(), randomInt(0, 1000000), random()]);`
+	first, second := NewInterpreter(), NewInterpreter()
+	first.SetRandomSeed(42)
+	second.SetRandomSeed(42)
+	if a, b := evaluate(t, first, src), evaluate(t, second, src); a != b {
+		t.Errorf("Expected the same random numbers for the same seed, got: %v and %v", a, b)
+	}
+
+	got := evaluate(t, NewInterpreter(), `seed(7); var a = `+src+` seed(7); var b = `+src+` a == b;`)
+	if got != true {
+		t.Errorf("Expected seed() to restart the sequence")
+	}
+}
+
+func TestStrings(t *testing.T) {
+	cases := map[string]interface{}{
+		`len("héllo")`:                                      float64(5),
+		`len([1, 2]) + len({a: 1})`:                         float64(3),
+		`substring("héllo", 1, 3)`:                          "él",
+		`substring("héllo", 2)`:                             "llo",
+		`slice("héllo", -3)`:                                "llo",
+		`slice("héllo", 1, -1)`:                             "éll",
+		`slice("abc", 5, 10)`:                               "",
+		`str(slice([1, 2, 3, 4], 1, 3))`:                    "[2, 3]",
+		`indexOf("héllo wörld", "wö")`:                      float64(6),
+		`indexOf("abcabc", "c", 3)`:                         float64(5),
+		`indexOf("abc", "d")`:                               float64(-1),
+		`indexOf([1, "a", nil], nil)`:                       float64(2),
+		`str(split("a,b,,c", ","))`:                         "[a, b, , c]",
+		`str(split("hé", ""))`:                              "[h, é]",
+		`join(["a", 1, true], "-")`:                         "a-1-true",
+		`trim("  hi\n")`:                                    "hi",
+		`upper("héllo") + lower(" ÉCOLE")`:                  "HÉLLO école",
+		`replace("a-b-c", "-", "+")`:                        "a+b+c",
+		`startsWith("lox", "lo") and endsWith("lox", "ox")`: true,
+		`startsWith("lox", "ox")`:                           false,
+		`charAt("héllo", 1)`:                                "é",
+		`ord("é")`:                                          float64(233),
+		`chr(128512)`:                                       "\U0001F600",
+		`toNumber(" 3.5 ") + toNumber(2)`:                   5.5,
+		`toNumber("abc")`:                                   nil,
+		`toNumber("0x10") + toNumber("0b11")`:               float64(19),
+		`toNumber("-1_000.5")`:                              -1000.5,
+		`toNumber("2e3")`:                                   float64(2000),
+		`toNumber("inf")`:                                   nil,
+		`toNumber("NaN")`:                                   nil,
+		`toNumber("1__0")`:                                  nil,
+		`toNumber(".5")`:                                    nil,
+		`toNumber("")`:                                      nil,
+		`toString(3.14159, 2)`:                              "3.14",
+		`toString(2, 0)`:                                    "2",
+		`toString([1, nil])`:                                "[1, nil]",
+	}
+	for src, expected := range cases {
+		got := evaluate(t, NewInterpreter(), src+`;`)
+		if got != expected {
+			t.Errorf("%s expected: %v, got: %v", src, expected, got)
+		}
+	}
+
+	failures := map[string]string{
+		`len(1);`:                 "len: argument 1 must be a string, list or map, got number",
+		`upper(nil);`:             "upper: argument 1 must be a string, got nil",
+		`substring("abc", 2, 1);`: "substring: end 1 is out of range for start 2 and length 3",
+		`substring("abc", 4);`:    "substring: start 4 is out of range for length 3",
+		`charAt("abc", 3);`:       "charAt: index 3 is out of range for length 3",
+		`charAt("abc", 0.5);`:     "charAt: argument 2 must be an integer, got 0.5",
+		`ord("ab");`:              `ord: argument 1 must be a single character, got "ab"`,
+		`chr(55296);`:             "chr: 55296 is not a valid code point",
+		`join("abc", "");`:        "join: argument 1 must be a list, got string",
+		`toString(1, 101);`:       "toString: precision must be between 0 and 100, got 101",
+		`substring("abc");`:       "expected 2 to 3 arguments but got 1",
+	}
+	for src, expected := range failures {
+		got := runtimeErrorMessage(t, NewInterpreter(), src)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+}
+
+func TestIO(t *testing.T) {
+	dir := writeModules(t, map[string]string{"config.txt": "port=80\n", "lib/a.lox": ""})
+	defer os.RemoveAll(dir)
+
+	interp := NewInterpreter()
+	interp.globals.Define("dir", value.NewString(dir))
+	interp.SetArgs([]string{"-v", "out.txt"})
+	interp.SetStdin(strings.NewReader("first\r\nsecond"))
+	os.Setenv("LOX_TEST_ENV", "set")
+	defer os.Unsetenv("LOX_TEST_ENV")
+
+	cases := []struct {
+		src      string
+		expected interface{}
+	}{
+		{`readFile(dir + "/config.txt");`, "port=80\n"},
+		{`writeFile(dir + "/report.txt", "a");`, nil},
+		{`appendFile(dir + "/report.txt", "b"); readFile(dir + "/report.txt");`, "ab"},
+		{`appendFile(dir + "/new.txt", "c"); readFile(dir + "/new.txt");`, "c"},
+		{`str(listDir(dir));`, "[config.txt, lib, new.txt, report.txt]"},
+		{`exists(dir + "/lib") and !exists(dir + "/missing");`, true},
+		{`str(args());`, "[-v, out.txt]"},
+		{`env("LOX_TEST_ENV");`, "set"},
+		{`env("LOX_TEST_UNSET_ENV");`, nil},
+		{`var first = readLine(); first + " " + readLine();`, "first second"},
+		{`readLine();`, nil},
+	}
+	for _, c := range cases {
+		if got := evaluate(t, interp, c.src); got != c.expected {
+			t.Errorf("%s expected: %v, got: %v", c.src, c.expected, got)
+		}
+	}
+
+	exitCode := -1
+	interp.exit = func(code int) { exitCode = code }
+	evaluate(t, interp, `exit(3);`)
+	if exitCode != 3 {
+		t.Errorf("Expected exit code 3, got: %d", exitCode)
+	}
+
+	got := runtimeErrorMessage(t, interp, `readFile(dir + "/missing");`)
+	if !strings.HasPrefix(got, "readFile: open ") {
+		t.Errorf("Expected a readFile error, got: %v", got)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	dir := writeModules(t, map[string]string{"config.txt": "port=80\n", "lib.lox": ""})
+	defer os.RemoveAll(dir)
+
+	failures := map[string]string{
+		`readFile(dir + "/config.txt");`: "readFile: filesystem access is disabled",
+		`writeFile(dir + "/x.txt", "");`: "writeFile: filesystem access is disabled",
+		`exists(dir);`:                   "exists: filesystem access is disabled",
+		`import "lib.lox" as lib;`:       "import: filesystem access is disabled",
+		`env("HOME");`:                   "env: process access is disabled",
+		`args();`:                        "args: process access is disabled",
+		`exit(1);`:                       "exit: process access is disabled",
+	}
+	for src, expected := range failures {
+		interp := NewInterpreter()
+		interp.SetScriptPath(filepath.Join(dir, "main.lox"))
+		interp.globals.Define("dir", value.NewString(dir))
+		interp.SetCapabilities(CapNone)
+		interp.exit = func(code int) { t.Errorf("%s: exited with %d", src, code) }
+		got := runtimeErrorMessage(t, interp, src)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+
+	interp := NewInterpreter()
+	interp.globals.Define("dir", value.NewString(dir))
+	interp.SetCapabilities(CapFiles)
+	if got := evaluate(t, interp, `exists(dir + "/config.txt");`); got != true {
+		t.Errorf("Expected files to be allowed, got: %v", got)
+	}
+	if _, err := interp.EvalString(`args()`); err == nil || !strings.Contains(err.Error(), "process access is disabled") {
+		t.Errorf("Expected process access to be disabled, got: %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	cases := map[string]interface{}{
+		`var v = jsonParse("{\"b\": [1, 2.5, true, null], \"a\": {\"x\": \"\\u00e9\"}}"); str(v);`: "{b: [1, 2.5, true, nil], a: {x: é}}",
+		`jsonParse(" 42 ");`:                                    float64(42),
+		`jsonParse("\"hi\"");`:                                  "hi",
+		`jsonParse("null");`:                                    nil,
+		`jsonParse("[[]]")[0] == nil;`:                          false,
+		`jsonStringify({b: [1, nil, "q\"<"], a: {}, "c": []});`: `{"b":[1,null,"q\"<"],"a":{},"c":[]}`,
+		`jsonStringify({1: true});`:                             `{"1":true}`,
+		`jsonStringify(1e21) + jsonStringify(0.5);`:             "1e+210.5",
+		`jsonStringify({a: [1, 2], b: {}}, 2);`:                 "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}",
+		`jsonStringify([1], "\t");`:                             "[\n\t1\n]",
+		`var s = "{\"k\":[1,\"two\",{\"z\":null}]}"; jsonStringify(jsonParse(s)) == s;`: true,
+		`var shared = [1]; jsonStringify([shared, shared]);`:                            "[[1],[1]]",
+	}
+	for src, expected := range cases {
+		got := evaluate(t, NewInterpreter(), src)
+		if got != expected {
+			t.Errorf("%s expected: %q, got: %q", src, expected, got)
+		}
+	}
+
+	failures := map[string]string{
+		`jsonParse("[1,");`:                              "jsonParse: unexpected end of JSON input at offset 3",
+		`jsonParse("{\"a\" 1}");`:                        "jsonParse: invalid character '1' after object key at offset 6",
+		`jsonParse("[1] 2");`:                            "jsonParse: unexpected data after offset 3",
+		`jsonParse(1);`:                                  "jsonParse: argument 1 must be a string, got number",
+		`fun f() {} jsonStringify([f]);`:                 "jsonStringify: cannot serialize function",
+		`jsonStringify(sqrt(-1));`:                       "jsonStringify: cannot serialize NaN",
+		`var xs = [1]; xs[0] = xs; jsonStringify(xs);`:   "jsonStringify: cannot serialize a cyclic list",
+		`var m = {}; m["self"] = [m]; jsonStringify(m);`: "jsonStringify: cannot serialize a cyclic map",
+		`jsonStringify({true: 1});`:                      "jsonStringify: cannot serialize map key true, keys must be strings or numbers",
+		`jsonStringify([], nil);`:                        "jsonStringify: argument 2 must be a number or string, got nil",
+	}
+	for src, expected := range failures {
+		got := runtimeErrorMessage(t, NewInterpreter(), src)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+}
+
+// fakeClock is a Clock which only moves when slept on
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestTime(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, time.February, 29, 13, 5, 9, 250*int(time.Millisecond), time.UTC)}
+	interp := NewInterpreter()
+	interp.SetClock(clock)
+
+	cases := []struct {
+		src      string
+		expected interface{}
+	}{
+		{`now();`, float64(1709211909250)},
+		{`clock();`, 1709211909.25},
+		{`var start = clock(); sleep(1500); clock() - start;`, 1.5},
+		{`formatTime(now());`, "2024-02-29T13:05:10.750Z"},
+		{`formatTime(0, "%d/%m/%Y %H:%M:%S %%");`, "01/01/1970 00:00:00 %"},
+		{`parseTime("2024-02-29T13:05:10.750Z") == now();`, true},
+		{`parseTime("1970-01-02", "%Y-%m-%d");`, float64(86400000)},
+		{`formatTime(-86400000, "%Y-%m-%d");`, "1969-12-31"},
+		{`formatTime(9223372036854, "%Y");`, "2262"},
+		{`parseTime(formatTime(12345678, "%H:%M:%S.%L"), "%H:%M:%S.%L");`, float64(12345678)},
+	}
+	for _, c := range cases {
+		if got := evaluate(t, interp, c.src); got != c.expected {
+			t.Errorf("%s expected: %v, got: %v", c.src, c.expected, got)
+		}
+	}
+	if expected := time.Date(2024, time.February, 29, 13, 5, 10, 750*int(time.Millisecond), time.UTC); !clock.now.Equal(expected) {
+		t.Errorf("Expected sleep to advance the clock to %v, got: %v", expected, clock.now)
+	}
+
+	failures := map[string]string{
+		`sleep(-1);`:                            "sleep: duration must not be negative, got -1",
+		`sleep(1/0);`:                           "sleep: argument 1 must be a finite number, got Infinity",
+		`formatTime(0/0);`:                      "formatTime: argument 1 must be a finite number, got NaN",
+		`formatTime(-1/0);`:                     "formatTime: argument 1 must be a finite number, got -Infinity",
+		`formatTime(1e20);`:                     "formatTime: 100000000000000000000 milliseconds is out of range",
+		`formatTime(0, "%Q");`:                  "formatTime: unknown directive %Q",
+		`parseTime("2023-02-29", "%Y-%m-%d");`:  `parseTime: "2023-02-29" is not a valid time`,
+		`parseTime("2023-1-02", "%Y-%m-%d");`:   `parseTime: "1-" is not a %m field at offset 5`,
+		`parseTime("2023-01", "%Y-%m-%d");`:     `parseTime: "2023-01" does not match "%Y-%m-%d" at offset 7`,
+		`parseTime("2023-01-01Z", "%Y-%m-%d");`: `parseTime: unexpected "Z" after the time`,
+	}
+	for src, expected := range failures {
+		got := runtimeErrorMessage(t, interp, src)
+		if got != expected {
+			t.Errorf("%s expected: %s, got: %v", src, expected, got)
+		}
+	}
+}
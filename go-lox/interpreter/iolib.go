@@ -0,0 +1,182 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/vn-ki/go-lox/env"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// Capability is a set of things scripts are allowed to do outside of the
+// interpreter
+type Capability uint
+
+const (
+	// CapFiles allows reading and writing files with the file natives, and
+	// importing modules
+	CapFiles Capability = 1 << iota
+	// CapProcess allows reading stdin, the script arguments and environment
+	// variables, and exiting the process
+	CapProcess
+
+	// CapNone disables every capability, for running untrusted scripts
+	CapNone Capability = 0
+	// CapAll is the default of NewInterpreter
+	CapAll = CapFiles | CapProcess
+)
+
+func (c Capability) String() string {
+	switch c {
+	case CapFiles:
+		return "filesystem"
+	case CapProcess:
+		return "process"
+	}
+	return fmt.Sprintf("Capability(%d)", uint(c))
+}
+
+// SetCapabilities sets what scripts are allowed to do. The natives which
+// need a missing capability are still defined, but fail with a runtime error.
+func (i *Interpreter) SetCapabilities(caps Capability) {
+	i.capabilities = caps
+}
+
+// SetArgs sets the script arguments returned by args()
+func (i *Interpreter) SetArgs(args []string) {
+	i.args = args
+}
+
+// SetStdin sets the reader readLine() reads from. It is os.Stdin by default.
+func (i *Interpreter) SetStdin(r io.Reader) {
+	i.stdin = bufio.NewReader(r)
+}
+
+// checkCapability returns an error if the native name needs a capability
+// the interpreter doesn't have
+func (i *Interpreter) checkCapability(name string, c Capability) error {
+	if i.capabilities&c == 0 {
+		return fmt.Errorf("%s: %s access is disabled", name, c)
+	}
+	return nil
+}
+
+// fileNative returns a native which needs the CapFiles capability and takes
+// a path as its first argument
+func (i *Interpreter) fileNative(name string, arity int, fn func(path string, args []Value) (Value, error)) Value {
+	return newNative(name, arity, func(args []Value) (Value, error) {
+		if err := i.checkCapability(name, CapFiles); err != nil {
+			return value.Nil, err
+		}
+		path, err := stringArg(name, args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		ret, err := fn(path, args)
+		if err != nil {
+			return value.Nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return ret, nil
+	})
+}
+
+// processNative returns a native which needs the CapProcess capability
+func (i *Interpreter) processNative(name string, arity int, fn func(args []Value) (Value, error)) Value {
+	return newNative(name, arity, func(args []Value) (Value, error) {
+		if err := i.checkCapability(name, CapProcess); err != nil {
+			return value.Nil, err
+		}
+		return fn(args)
+	})
+}
+
+// defineIO defines the file and process natives in a global environment
+func (i *Interpreter) defineIO(globals *env.Environemnt) {
+	globals.Define("readFile", i.fileNative("readFile", 1, func(path string, args []Value) (Value, error) {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return value.Nil, err
+		}
+		return value.NewString(string(content)), nil
+	}))
+	globals.Define("writeFile", i.fileNative("writeFile", 2, func(path string, args []Value) (Value, error) {
+		content, err := stringArg("writeFile", args, 1)
+		if err != nil {
+			return value.Nil, err
+		}
+		return value.Nil, ioutil.WriteFile(path, []byte(content), 0644)
+	}))
+	globals.Define("appendFile", i.fileNative("appendFile", 2, func(path string, args []Value) (Value, error) {
+		content, err := stringArg("appendFile", args, 1)
+		if err != nil {
+			return value.Nil, err
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return value.Nil, err
+		}
+		if _, err := f.WriteString(content); err != nil {
+			f.Close()
+			return value.Nil, err
+		}
+		return value.Nil, f.Close()
+	}))
+	globals.Define("listDir", i.fileNative("listDir", 1, func(path string, args []Value) (Value, error) {
+		infos, err := ioutil.ReadDir(path)
+		if err != nil {
+			return value.Nil, err
+		}
+		names := make([]Value, len(infos))
+		for idx, info := range infos {
+			names[idx] = value.NewString(info.Name())
+		}
+		return value.NewObject(NewLoxList(names)), nil
+	}))
+	globals.Define("exists", i.fileNative("exists", 1, func(path string, args []Value) (Value, error) {
+		_, err := os.Stat(path)
+		return value.NewBool(err == nil), nil
+	}))
+
+	globals.Define("readLine", i.processNative("readLine", 0, func(args []Value) (Value, error) {
+		if i.stdin == nil {
+			i.stdin = bufio.NewReader(os.Stdin)
+		}
+		line, err := i.stdin.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return value.Nil, nil
+		}
+		if err != nil && err != io.EOF {
+			return value.Nil, fmt.Errorf("readLine: %v", err)
+		}
+		return value.NewString(strings.TrimRight(line, "\r\n")), nil
+	}))
+	globals.Define("args", i.processNative("args", 0, func(args []Value) (Value, error) {
+		elements := make([]Value, len(i.args))
+		for idx, arg := range i.args {
+			elements[idx] = value.NewString(arg)
+		}
+		return value.NewObject(NewLoxList(elements)), nil
+	}))
+	globals.Define("env", i.processNative("env", 1, func(args []Value) (Value, error) {
+		name, err := stringArg("env", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			return value.NewString(v), nil
+		}
+		return value.Nil, nil
+	}))
+	globals.Define("exit", i.processNative("exit", 1, func(args []Value) (Value, error) {
+		code, err := integerArg("exit", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		i.exit(int(code))
+		return value.Nil, nil
+	}))
+}
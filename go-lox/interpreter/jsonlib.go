@@ -0,0 +1,248 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/vn-ki/go-lox/env"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// defineJSON defines jsonParse and jsonStringify in a global environment
+func (i *Interpreter) defineJSON(globals *env.Environemnt) {
+	globals.Define("jsonParse", newNative("jsonParse", 1, func(args []Value) (Value, error) {
+		src, err := stringArg("jsonParse", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		v, err := parseJSON(src)
+		if err != nil {
+			return value.Nil, fmt.Errorf("jsonParse: %v", err)
+		}
+		return v, nil
+	}))
+	globals.Define("jsonStringify", newNativeRange("jsonStringify", 1, 2, func(args []Value) (Value, error) {
+		indent := ""
+		if len(args) == 2 {
+			switch {
+			case args[1].IsString():
+				indent = args[1].AsString()
+			case args[1].IsNumber():
+				n, err := integerArg("jsonStringify", args, 1)
+				if err != nil {
+					return value.Nil, err
+				}
+				if n < 0 || n > 10 {
+					return value.Nil, fmt.Errorf("jsonStringify: indent must be between 0 and 10, got %d", n)
+				}
+				indent = strings.Repeat(" ", int(n))
+			default:
+				return value.Nil, fmt.Errorf("jsonStringify: argument 2 must be a number or string, got %s", args[1].TypeName())
+			}
+		}
+		s := &jsonStringifier{indent: indent, visiting: make(map[value.Object]bool)}
+		if err := s.write(args[0], 0); err != nil {
+			return value.Nil, fmt.Errorf("jsonStringify: %v", err)
+		}
+		return value.NewString(s.buf.String()), nil
+	}))
+}
+
+/// Parsing
+
+// parseJSON decodes src into lox values. Objects become maps which keep
+// the order of their keys.
+func parseJSON(src string) (Value, error) {
+	dec := json.NewDecoder(strings.NewReader(src))
+	v, err := decodeJSON(dec)
+	if err != nil {
+		return value.Nil, jsonSyntaxError(err)
+	}
+	end := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		return value.Nil, fmt.Errorf("unexpected data after offset %d", end)
+	}
+	return v, nil
+}
+
+// jsonSyntaxError adds the offset to a syntax error. Empty input is reported
+// as the end of input, like a truncated value.
+func jsonSyntaxError(err error) error {
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		return fmt.Errorf("%v at offset %d", err, syntax.Offset)
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.New("unexpected end of JSON input")
+	}
+	return err
+}
+
+// decodeJSON decodes the next value of dec
+func decodeJSON(dec *json.Decoder) (Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return value.Nil, err
+	}
+	switch tok := tok.(type) {
+	case nil:
+		return value.Nil, nil
+	case bool:
+		return value.NewBool(tok), nil
+	case float64:
+		return value.NewNumber(tok), nil
+	case string:
+		return value.NewString(tok), nil
+	case json.Delim:
+		if tok == '[' {
+			elements := []Value{}
+			for dec.More() {
+				element, err := decodeJSON(dec)
+				if err != nil {
+					return value.Nil, err
+				}
+				elements = append(elements, element)
+			}
+			if _, err := dec.Token(); err != nil {
+				return value.Nil, err
+			}
+			return value.NewObject(NewLoxList(elements)), nil
+		}
+		m := NewLoxMap()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return value.Nil, err
+			}
+			val, err := decodeJSON(dec)
+			if err != nil {
+				return value.Nil, err
+			}
+			m.Set(value.NewString(key.(string)), val)
+		}
+		if _, err := dec.Token(); err != nil {
+			return value.Nil, err
+		}
+		return value.NewObject(m), nil
+	}
+	return value.Nil, fmt.Errorf("unexpected JSON token %v", tok)
+}
+
+/// Stringifying
+
+type jsonStringifier struct {
+	buf    bytes.Buffer
+	indent string
+	// the lists and maps being written, to detect cycles
+	visiting map[value.Object]bool
+}
+
+// write writes v at the nesting depth
+func (s *jsonStringifier) write(v Value, depth int) error {
+	switch v.Kind() {
+	case value.KindNil:
+		s.buf.WriteString("null")
+		return nil
+	case value.KindBool:
+		s.buf.WriteString(v.String())
+		return nil
+	case value.KindNumber:
+		if n := v.AsNumber(); math.IsNaN(n) || math.IsInf(n, 0) {
+			return fmt.Errorf("cannot serialize %v", v)
+		}
+		s.buf.WriteString(v.String())
+		return nil
+	case value.KindString:
+		s.writeString(v.AsString())
+		return nil
+	}
+
+	obj := v.AsObject()
+	switch obj.(type) {
+	case *LoxList, *LoxMap:
+	default:
+		return fmt.Errorf("cannot serialize %s", v.TypeName())
+	}
+	if s.visiting[obj] {
+		return fmt.Errorf("cannot serialize a cyclic %s", v.TypeName())
+	}
+	s.visiting[obj] = true
+	defer delete(s.visiting, obj)
+
+	if list, ok := obj.(*LoxList); ok {
+		s.buf.WriteByte('[')
+		for idx, element := range list.Elements {
+			s.separator(idx, depth+1)
+			if err := s.write(element, depth+1); err != nil {
+				return err
+			}
+		}
+		s.closing(len(list.Elements), depth)
+		s.buf.WriteByte(']')
+		return nil
+	}
+
+	m := obj.(*LoxMap)
+	s.buf.WriteByte('{')
+	for idx, key := range m.Keys() {
+		s.separator(idx, depth+1)
+		switch {
+		case key.IsString():
+			s.writeString(key.AsString())
+		case key.IsNumber():
+			s.writeString(key.String())
+		default:
+			return fmt.Errorf("cannot serialize map key %v, keys must be strings or numbers", key)
+		}
+		s.buf.WriteByte(':')
+		if s.indent != "" {
+			s.buf.WriteByte(' ')
+		}
+		val, _ := m.Get(key)
+		if err := s.write(val, depth+1); err != nil {
+			return err
+		}
+	}
+	s.closing(m.Len(), depth)
+	s.buf.WriteByte('}')
+	return nil
+}
+
+// separator writes what goes before the element at idx of a list or map
+func (s *jsonStringifier) separator(idx, depth int) {
+	if idx > 0 {
+		s.buf.WriteByte(',')
+	}
+	s.newline(depth)
+}
+
+// closing writes what goes before the closing bracket of a list or map with
+// length elements
+func (s *jsonStringifier) closing(length, depth int) {
+	if length > 0 {
+		s.newline(depth)
+	}
+}
+
+func (s *jsonStringifier) newline(depth int) {
+	if s.indent == "" {
+		return
+	}
+	s.buf.WriteByte('\n')
+	for idx := 0; idx < depth; idx++ {
+		s.buf.WriteString(s.indent)
+	}
+}
+
+func (s *jsonStringifier) writeString(str string) {
+	enc := json.NewEncoder(&s.buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(str)
+	// Encode ends the value with a newline
+	s.buf.Truncate(s.buf.Len() - 1)
+}
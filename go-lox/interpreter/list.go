@@ -0,0 +1,113 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/vn-ki/go-lox/token"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// LoxList is a lox list. Lists are passed around by reference.
+type LoxList struct {
+	Elements []Value
+}
+
+func NewLoxList(elements []Value) *LoxList {
+	return &LoxList{Elements: elements}
+}
+
+func (l *LoxList) TypeName() string { return "list" }
+
+func (l *LoxList) String() string {
+	return newStringifier().format(value.NewObject(l))
+}
+
+// stringifier formats lists and maps, which can contain themselves. A list
+// or map inside itself is printed as `[...]` or `{...}`.
+type stringifier struct {
+	// the lists and maps being formatted, to detect cycles
+	visiting map[value.Object]bool
+}
+
+func newStringifier() *stringifier {
+	return &stringifier{visiting: make(map[value.Object]bool)}
+}
+
+func (s *stringifier) format(v Value) string {
+	switch obj := v.AsObject().(type) {
+	case *LoxList:
+		if s.visiting[obj] {
+			return "[...]"
+		}
+		s.visiting[obj] = true
+		defer delete(s.visiting, obj)
+		elements := make([]string, len(obj.Elements))
+		for idx, element := range obj.Elements {
+			elements[idx] = s.format(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *LoxMap:
+		if s.visiting[obj] {
+			return "{...}"
+		}
+		s.visiting[obj] = true
+		defer delete(s.visiting, obj)
+		entries := make([]string, len(obj.keys))
+		for idx, key := range obj.keys {
+			entries[idx] = s.format(key) + ": " + s.format(obj.entries[key])
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+	return v.String()
+}
+
+// indexGet evaluates `object[index]` for lists and maps
+func (i *Interpreter) indexGet(bracket token.Token, object Value, index Value) Value {
+	switch v := object.AsObject().(type) {
+	case *LoxList:
+		return v.Elements[i.checkIndex(bracket, v, index)]
+	case *LoxMap:
+		i.checkKey(bracket, index)
+		// missing keys are nil, so that `m[key] ?? default` works
+		val, _ := v.Get(index)
+		return val
+	}
+	i.err(fmt.Sprintf("%s can't be indexed", object.TypeName()), bracket)
+	return value.Nil
+}
+
+// indexSet evaluates `object[index] = val` for lists and maps
+func (i *Interpreter) indexSet(bracket token.Token, object Value, index Value, val Value) {
+	switch v := object.AsObject().(type) {
+	case *LoxList:
+		v.Elements[i.checkIndex(bracket, v, index)] = val
+		return
+	case *LoxMap:
+		i.checkKey(bracket, index)
+		v.Set(index, val)
+		return
+	}
+	i.err(fmt.Sprintf("%s can't be indexed", object.TypeName()), bracket)
+}
+
+func (i *Interpreter) checkKey(bracket token.Token, key Value) {
+	if !key.IsHashable() {
+		i.err(fmt.Sprintf("%v can't be used as a map key", key), bracket)
+	}
+}
+
+// checkIndex checks that index is a valid index into list and returns it
+// as an int
+func (i *Interpreter) checkIndex(bracket token.Token, list *LoxList, index Value) int {
+	idx := index.AsNumber()
+	if !index.IsNumber() || math.Trunc(idx) != idx {
+		i.err(fmt.Sprintf("list index must be an integer, got %v", index), bracket)
+	}
+	// compare as floats, int(idx) is undefined for huge indexes
+	if idx < 0 || idx >= float64(len(list.Elements)) {
+		i.err(fmt.Sprintf("list index %v out of range", idx), bracket)
+	}
+	return int(idx)
+}
@@ -0,0 +1,39 @@
+package interpreter
+
+import "github.com/vn-ki/go-lox/value"
+
+// LoxMap is a lox map. Keys are kept in insertion order. Like lists, maps
+// are passed around by reference.
+type LoxMap struct {
+	entries map[Value]Value
+	keys    []Value
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{entries: make(map[Value]Value), keys: make([]Value, 0)}
+}
+
+func (m *LoxMap) Get(key Value) (Value, bool) {
+	val, ok := m.entries[key]
+	return val, ok
+}
+
+func (m *LoxMap) Set(key Value, val Value) {
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = val
+}
+
+// Keys returns the keys of the map in insertion order
+func (m *LoxMap) Keys() []Value {
+	return append([]Value{}, m.keys...)
+}
+
+func (m *LoxMap) Len() int { return len(m.keys) }
+
+func (m *LoxMap) TypeName() string { return "map" }
+
+func (m *LoxMap) String() string {
+	return newStringifier().format(value.NewObject(m))
+}
@@ -0,0 +1,63 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/vn-ki/go-lox/ast"
+	"github.com/vn-ki/go-lox/env"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// matchPattern checks whether value matches pattern, defining the names the
+// pattern binds in bindings as it goes. The error says why it didn't match.
+func (i *Interpreter) matchPattern(pattern ast.Pattern, val Value, bindings *env.Environemnt) error {
+	switch p := pattern.(type) {
+	case ast.Pliteral:
+		if literal := literalValue(p.Value); !literal.Equals(val) {
+			return fmt.Errorf("expected %v but got %v", literal, val)
+		}
+		return nil
+	case ast.Pwildcard:
+		return nil
+	case ast.Pbinding:
+		bindings.Define(p.Name.Lexeme, val)
+		return nil
+	case ast.Plist:
+		list, ok := val.AsObject().(*LoxList)
+		if !ok {
+			return fmt.Errorf("expected a list but got %v", val)
+		}
+		if p.Rest == nil && len(list.Elements) != len(p.Elements) {
+			return fmt.Errorf("expected a list of %d elements but got %d", len(p.Elements), len(list.Elements))
+		}
+		if len(list.Elements) < len(p.Elements) {
+			return fmt.Errorf("expected a list of at least %d elements but got %d", len(p.Elements), len(list.Elements))
+		}
+		for idx, element := range p.Elements {
+			if err := i.matchPattern(element, list.Elements[idx], bindings); err != nil {
+				return err
+			}
+		}
+		if p.Rest != nil && p.Rest.Lexeme != "_" {
+			rest := append([]Value{}, list.Elements[len(p.Elements):]...)
+			bindings.Define(p.Rest.Lexeme, value.NewObject(NewLoxList(rest)))
+		}
+		return nil
+	case ast.Pmap:
+		m, ok := val.AsObject().(*LoxMap)
+		if !ok {
+			return fmt.Errorf("expected a map but got %v", val)
+		}
+		for idx, key := range p.Keys {
+			entry, ok := m.Get(literalValue(key))
+			if !ok {
+				return fmt.Errorf("missing key %v", literalValue(key))
+			}
+			if err := i.matchPattern(p.Values[idx], entry, bindings); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	panic("Unreachable")
+}
@@ -0,0 +1,132 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/vn-ki/go-lox/env"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// the one argument natives which apply a math function to a number
+var mathFunctions = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"round": math.Round,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"log":   math.Log,
+	"exp":   math.Exp,
+}
+
+// defineMath defines the math natives and constants in a global environment
+func (i *Interpreter) defineMath(globals *env.Environemnt) {
+	globals.DefineConst("PI", value.NewNumber(math.Pi))
+	globals.DefineConst("E", value.NewNumber(math.E))
+
+	for name, fn := range mathFunctions {
+		name, fn := name, fn
+		globals.Define(name, newNative(name, 1, func(args []Value) (Value, error) {
+			n, err := numberArg(name, args, 0)
+			if err != nil {
+				return value.Nil, err
+			}
+			return value.NewNumber(fn(n)), nil
+		}))
+	}
+	globals.Define("pow", newNative("pow", 2, func(args []Value) (Value, error) {
+		x, err := numberArg("pow", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		y, err := numberArg("pow", args, 1)
+		if err != nil {
+			return value.Nil, err
+		}
+		return value.NewNumber(math.Pow(x, y)), nil
+	}))
+	globals.Define("min", newNative("min", -1, func(args []Value) (Value, error) {
+		return extremum("min", args, math.Min)
+	}))
+	globals.Define("max", newNative("max", -1, func(args []Value) (Value, error) {
+		return extremum("max", args, math.Max)
+	}))
+
+	globals.Define("random", newNative("random", 0, func(args []Value) (Value, error) {
+		return value.NewNumber(i.random.Float64()), nil
+	}))
+	globals.Define("randomInt", newNative("randomInt", 2, func(args []Value) (Value, error) {
+		min, err := integerArg("randomInt", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		max, err := integerArg("randomInt", args, 1)
+		if err != nil {
+			return value.Nil, err
+		}
+		if min > max {
+			return value.Nil, fmt.Errorf("randomInt: min %d is greater than max %d", min, max)
+		}
+		return value.NewNumber(float64(min + i.random.Int63n(max-min+1))), nil
+	}))
+	globals.Define("seed", newNative("seed", 1, func(args []Value) (Value, error) {
+		seed, err := integerArg("seed", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		i.SetRandomSeed(seed)
+		return value.Nil, nil
+	}))
+}
+
+// SetRandomSeed seeds random() and randomInt(), so that scripts using them
+// give the same results on every run
+func (i *Interpreter) SetRandomSeed(seed int64) {
+	i.random = rand.New(rand.NewSource(seed))
+}
+
+// extremum returns the smallest or largest of the numbers in args, as picked
+// by pick
+func extremum(name string, args []Value, pick func(float64, float64) float64) (Value, error) {
+	if len(args) == 0 {
+		return value.Nil, fmt.Errorf("%s: expected at least 1 arguments but got 0", name)
+	}
+	result, err := numberArg(name, args, 0)
+	if err != nil {
+		return value.Nil, err
+	}
+	for idx := 1; idx < len(args); idx++ {
+		n, err := numberArg(name, args, idx)
+		if err != nil {
+			return value.Nil, err
+		}
+		result = pick(result, n)
+	}
+	return value.NewNumber(result), nil
+}
+
+// numberArg checks that the argument at idx of the native name is a number
+// and returns it
+func numberArg(name string, args []Value, idx int) (float64, error) {
+	if !args[idx].IsNumber() {
+		return 0, fmt.Errorf("%s: argument %d must be a number, got %s", name, idx+1, args[idx].TypeName())
+	}
+	return args[idx].AsNumber(), nil
+}
+
+// integerArg checks that the argument at idx of the native name is a number
+// with no fractional part and returns it
+func integerArg(name string, args []Value, idx int) (int64, error) {
+	n, err := numberArg(name, args, idx)
+	if err != nil {
+		return 0, err
+	}
+	if math.Trunc(n) != n || math.Abs(n) > maxSafeInteger {
+		return 0, fmt.Errorf("%s: argument %d must be an integer, got %v", name, idx+1, value.FormatNumber(n))
+	}
+	return int64(n), nil
+}
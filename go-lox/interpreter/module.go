@@ -0,0 +1,170 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/vn-ki/go-lox/ast"
+	"github.com/vn-ki/go-lox/env"
+	"github.com/vn-ki/go-lox/lexer"
+	"github.com/vn-ki/go-lox/parser"
+	"github.com/vn-ki/go-lox/token"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// LoxModule is what `import "path" as name;` binds to name. Only the
+// exported names of the module can be read from it.
+type LoxModule struct {
+	Name    string
+	Env     *env.Environemnt
+	Exports map[string]bool
+}
+
+func (m *LoxModule) Get(name token.Token) (Value, bool) {
+	if !m.Exports[name.Lexeme] {
+		return value.Nil, false
+	}
+	return m.Env.Get(name.Lexeme)
+}
+
+func (m *LoxModule) TypeName() string { return "module" }
+
+func (m *LoxModule) String() string { return fmt.Sprintf("<module %s>", m.Name) }
+
+// SetScriptPath sets the path of the script being interpreted. Imports are
+// resolved relative to it.
+func (i *Interpreter) SetScriptPath(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	i.path = path
+	i.importStack = []string{path}
+}
+
+func (i *Interpreter) VisitImport(s ast.Simport) interface{} {
+	i.declare(s.Name, value.NewObject(i.importModule(s.Path, s.Keyword)), false)
+	return nil
+}
+
+func (i *Interpreter) VisitExport(s ast.Sexport) interface{} {
+	i.execute(s.Declaration)
+	if i.module != nil {
+		for _, name := range declaredNames(s.Declaration) {
+			i.module.Exports[name.Lexeme] = true
+		}
+	}
+	return nil
+}
+
+// importModule returns the module at path, running it the first time it is
+// imported
+func (i *Interpreter) importModule(path string, tok token.Token) *LoxModule {
+	if err := i.checkCapability("import", CapFiles); err != nil {
+		i.err(err.Error(), tok)
+	}
+	dir, _ := filepath.Abs(".")
+	if i.path != "" {
+		dir = filepath.Dir(i.path)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	if module, ok := i.modules[path]; ok {
+		return module
+	}
+	for idx, importing := range i.importStack {
+		if importing == path {
+			cycle := append(append([]string{}, i.importStack[idx:]...), path)
+			for idx := range cycle {
+				cycle[idx] = filepath.Base(cycle[idx])
+			}
+			i.err("import cycle: "+strings.Join(cycle, " -> "), tok)
+		}
+	}
+
+	stmts, err := loadModule(path)
+	if err != nil {
+		i.err(err.Error(), tok)
+	}
+
+	module := &LoxModule{
+		Name:    strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Env:     env.NewEnvironment(nil),
+		Exports: make(map[string]bool),
+	}
+	i.defineNatives(module.Env)
+
+	prevEnv, prevGlobals, prevPath, prevModule := i.env, i.globals, i.path, i.module
+	defer func() {
+		i.env, i.globals, i.path, i.module = prevEnv, prevGlobals, prevPath, prevModule
+		i.importStack = i.importStack[:len(i.importStack)-1]
+	}()
+	i.env, i.globals, i.path, i.module = module.Env, module.Env, path, module
+	i.importStack = append(i.importStack, path)
+
+	for _, stmt := range stmts {
+		i.execute(stmt)
+	}
+	i.modules[path] = module
+	return module
+}
+
+// loadModule reads, parses and resolves the module at path
+func loadModule(path string) ([]ast.Stmt, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot import %s: %v", path, err)
+	}
+	stmts, err := parseSource(string(src))
+	if err != nil {
+		return nil, errors.New("in " + path + ": " + err.Error())
+	}
+	return stmts, nil
+}
+
+// parseSource lexes, parses and resolves src, returning all the errors
+// reported on the way as one error
+func parseSource(src string) ([]ast.Stmt, error) {
+	errs := make([]string, 0)
+	lexer := lexer.NewLexer(src)
+	lexer.ErrorHandler = func(line int, msg string) {
+		errs = append(errs, fmt.Sprintf("[line %d] %s", line, msg))
+	}
+	tokens := lexer.ScanTokens()
+	if len(errs) != 0 {
+		return nil, errors.New(strings.Join(errs, ", "))
+	}
+
+	parser := parser.NewParser(tokens)
+	parser.ErrorHandler = func(tok token.Token, msg string) {
+		errs = append(errs, fmt.Sprintf("[line %d] %s", tok.Line, msg))
+	}
+	stmts, hadError := parser.Parse()
+	if hadError {
+		return nil, errors.New(strings.Join(errs, ", "))
+	}
+
+	resolver := NewResolver()
+	resolver.ErrorHandler = parser.ErrorHandler
+	if resolver.Resolve(stmts) {
+		return nil, errors.New(strings.Join(errs, ", "))
+	}
+	return stmts, nil
+}
+
+// declaredNames returns the names a declaration statement defines
+func declaredNames(s ast.Stmt) []token.Token {
+	switch d := s.(type) {
+	case ast.Svar:
+		return []token.Token{d.Name}
+	case ast.Sfunction:
+		return []token.Token{d.Name}
+	case ast.Sdestructure:
+		return ast.PatternBindings(d.Pattern)
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vn-ki/go-lox/token"
+	"github.com/vn-ki/go-lox/value"
+)
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	valueType = reflect.TypeOf(value.Nil)
+	// the Go types lists and maps become when the Go side takes interface{}
+	goListType = reflect.TypeOf([]interface{}{})
+	goMapType  = reflect.TypeOf(map[string]interface{}{})
+)
+
+// Bind converts v with ToValue and defines it as a global called name. This
+// exposes Go functions and struct pointers to scripts without adapters.
+func (i *Interpreter) Bind(name string, v interface{}) error {
+	val, err := ToValue(v)
+	if err != nil {
+		return fmt.Errorf("cannot bind %s: %v", name, err)
+	}
+	if f, ok := val.AsObject().(*GoFunction); ok {
+		// closures don't have a useful name of their own
+		f.name = name
+	}
+	i.bindings[name] = val
+	i.globals.Define(name, val)
+	return nil
+}
+
+/// Go Function
+
+// GoFunction is a Go function or method called through reflection. Its
+// arguments are converted to the Go parameter types, and its results back to
+// lox values. A trailing error result is a runtime error if it isn't nil.
+type GoFunction struct {
+	name string
+	fn   reflect.Value
+}
+
+func (f *GoFunction) Arity() (int, int) {
+	t := f.fn.Type()
+	if t.IsVariadic() {
+		return t.NumIn() - 1, -1
+	}
+	return t.NumIn(), t.NumIn()
+}
+
+func (f *GoFunction) Call(_ *Interpreter, args []Value) Value {
+	t := f.fn.Type()
+	in := make([]reflect.Value, len(args))
+	for idx, arg := range args {
+		var paramType reflect.Type
+		if t.IsVariadic() && idx >= t.NumIn()-1 {
+			paramType = t.In(t.NumIn() - 1).Elem()
+		} else {
+			paramType = t.In(idx)
+		}
+		val, err := fromValue(arg, paramType)
+		if err != nil {
+			panic(nativeError{fmt.Errorf("%s: argument %d: %v", f.name, idx+1, err)})
+		}
+		in[idx] = val
+	}
+
+	out := f.fn.Call(in)
+	if n := len(out); n > 0 && t.Out(n-1) == errorType {
+		if err := out[n-1]; !err.IsNil() {
+			panic(nativeError{err.Interface().(error)})
+		}
+		out = out[:n-1]
+	}
+	results := make([]Value, len(out))
+	for idx, result := range out {
+		val, err := toValue(result)
+		if err != nil {
+			panic(nativeError{fmt.Errorf("%s: result %d: %v", f.name, idx+1, err)})
+		}
+		results[idx] = val
+	}
+	switch len(results) {
+	case 0:
+		return value.Nil
+	case 1:
+		return results[0]
+	}
+	// multiple results are returned as a list
+	return value.NewObject(NewLoxList(results))
+}
+
+func (f *GoFunction) TypeName() string { return "function" }
+
+func (f *GoFunction) String() string { return "<native fn>" }
+
+/// Go Object
+
+// GoObject is a pointer to a Go struct. Its exported fields and methods are
+// its properties, and can be written with a lower case first letter, so
+// `user.name` is the field Name.
+type GoObject struct {
+	value reflect.Value
+}
+
+// exportedName returns name with its first letter in upper case
+func exportedName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// field returns the exported field called name, or an invalid value
+func (o *GoObject) field(name string) reflect.Value {
+	s := o.value.Elem()
+	if f, ok := s.Type().FieldByName(exportedName(name)); !ok || f.PkgPath != "" {
+		return reflect.Value{}
+	}
+	return s.FieldByName(exportedName(name))
+}
+
+func (o *GoObject) Get(name token.Token) (Value, bool) {
+	if f := o.field(name.Lexeme); f.IsValid() {
+		if f.Kind() == reflect.Struct && f.Type() != valueType {
+			// point into the struct, so that `o.inner.x = 1` sets the field
+			// of o instead of the field of a copy
+			return value.NewObject(&GoObject{f.Addr()}), true
+		}
+		val, err := toValue(f)
+		if err != nil {
+			panic(runtimeError{fmt.Errorf("%s: %v", name.Lexeme, err), name})
+		}
+		return val, true
+	}
+	if m := o.value.MethodByName(exportedName(name.Lexeme)); m.IsValid() {
+		return value.NewObject(&GoFunction{name: name.Lexeme, fn: m}), true
+	}
+	return value.Nil, false
+}
+
+func (o *GoObject) Set(name token.Token, val Value) error {
+	f := o.field(name.Lexeme)
+	if !f.IsValid() {
+		return fmt.Errorf("undefined property '%s'", name.Lexeme)
+	}
+	v, err := fromValue(val, f.Type())
+	if err != nil {
+		return fmt.Errorf("cannot set %s: %v", name.Lexeme, err)
+	}
+	f.Set(v)
+	return nil
+}
+
+func (o *GoObject) TypeName() string { return "object" }
+
+// Interface returns the Go struct pointer
+func (o *GoObject) Interface() interface{} { return o.value.Interface() }
+
+func (o *GoObject) String() string { return fmt.Sprintf("<%s instance>", o.value.Elem().Type().Name()) }
+
+/// Conversions
+
+// ToValue converts a Go value to a lox value. Integers and floats become
+// numbers, slices and arrays become lists, maps become maps, functions
+// become a GoFunction and struct pointers become a GoObject. Structs are
+// copied into a new GoObject.
+func ToValue(v interface{}) (Value, error) {
+	switch v := v.(type) {
+	case Value:
+		return v, nil
+	case nil:
+		return value.Nil, nil
+	case bool:
+		return value.NewBool(v), nil
+	case float64:
+		return value.NewNumber(v), nil
+	case string:
+		return value.NewString(v), nil
+	case value.Object:
+		return value.NewObject(v), nil
+	}
+	return toValue(reflect.ValueOf(v))
+}
+
+func toValue(rv reflect.Value) (Value, error) {
+	switch rv.Kind() {
+	case reflect.Bool:
+		return value.NewBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.NewNumber(float64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.NewNumber(float64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return value.NewNumber(rv.Float()), nil
+	case reflect.String:
+		return value.NewString(rv.String()), nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]Value, rv.Len())
+		for idx := range elements {
+			val, err := toValue(rv.Index(idx))
+			if err != nil {
+				return value.Nil, err
+			}
+			elements[idx] = val
+		}
+		return value.NewObject(NewLoxList(elements)), nil
+	case reflect.Map:
+		m := NewLoxMap()
+		keys := make([]Value, 0, rv.Len())
+		values := make(map[Value]Value, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := toValue(iter.Key())
+			if err != nil {
+				return value.Nil, err
+			}
+			if !key.IsHashable() {
+				return value.Nil, fmt.Errorf("cannot use %v as a map key", key)
+			}
+			val, err := toValue(iter.Value())
+			if err != nil {
+				return value.Nil, err
+			}
+			keys = append(keys, key)
+			values[key] = val
+		}
+		// Go maps are unordered, so sort the keys to give the map a stable order
+		sort.Slice(keys, func(a, b int) bool { return keyLess(keys[a], keys[b]) })
+		for _, key := range keys {
+			m.Set(key, values[key])
+		}
+		return value.NewObject(m), nil
+	case reflect.Func:
+		if rv.IsNil() {
+			return value.Nil, nil
+		}
+		name := runtime.FuncForPC(rv.Pointer()).Name()
+		return value.NewObject(&GoFunction{name: name[strings.LastIndex(name, ".")+1:], fn: rv}), nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return value.Nil, nil
+		}
+		if rv.Elem().Kind() == reflect.Struct {
+			return value.NewObject(&GoObject{rv}), nil
+		}
+		return toValue(rv.Elem())
+	case reflect.Struct:
+		if rv.CanInterface() {
+			if v, ok := rv.Interface().(Value); ok {
+				return v, nil
+			}
+		}
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		return value.NewObject(&GoObject{ptr}), nil
+	case reflect.Interface:
+		if rv.IsNil() {
+			return value.Nil, nil
+		}
+		if rv.CanInterface() {
+			return ToValue(rv.Interface())
+		}
+		return toValue(rv.Elem())
+	}
+	return value.Nil, fmt.Errorf("cannot convert %s to a lox value", rv.Type())
+}
+
+// keyLess orders map keys converted from Go: numbers and strings in their
+// natural order, and keys of different types by kind
+func keyLess(a, b Value) bool {
+	if a.Kind() != b.Kind() {
+		return a.Kind() < b.Kind()
+	}
+	switch a.Kind() {
+	case value.KindBool:
+		return !a.AsBool() && b.AsBool()
+	case value.KindNumber:
+		return a.AsNumber() < b.AsNumber()
+	case value.KindString:
+		return a.AsString() < b.AsString()
+	}
+	return false
+}
+
+// fromValue converts a lox value to the Go type t
+func fromValue(v Value, t reflect.Type) (reflect.Value, error) {
+	if t == valueType {
+		return reflect.ValueOf(v), nil
+	}
+	if obj, ok := v.AsObject().(*GoObject); ok {
+		if obj.value.Type().AssignableTo(t) {
+			return obj.value, nil
+		}
+		if obj.value.Elem().Type().AssignableTo(t) {
+			return obj.value.Elem(), nil
+		}
+	}
+	if v.IsNil() {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot convert nil to %s", t)
+	}
+
+	rv := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Bool:
+		if v.IsBool() {
+			rv.SetBool(v.AsBool())
+			return rv, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.AsNumber(); v.IsNumber() {
+			// check the range before converting, since converting an out of
+			// range float to an integer is undefined
+			if limit := math.Ldexp(1, t.Bits()-1); n != math.Trunc(n) || n < -limit || n >= limit {
+				return reflect.Value{}, fmt.Errorf("%v is out of range for %s", n, t)
+			}
+			rv.SetInt(int64(n))
+			return rv, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n := v.AsNumber(); v.IsNumber() {
+			if n != math.Trunc(n) || n < 0 || n >= math.Ldexp(1, t.Bits()) {
+				return reflect.Value{}, fmt.Errorf("%v is out of range for %s", n, t)
+			}
+			rv.SetUint(uint64(n))
+			return rv, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if v.IsNumber() {
+			rv.SetFloat(v.AsNumber())
+			return rv, nil
+		}
+	case reflect.String:
+		if v.IsString() {
+			rv.SetString(v.AsString())
+			return rv, nil
+		}
+	case reflect.Slice:
+		if l, ok := v.AsObject().(*LoxList); ok {
+			rv = reflect.MakeSlice(t, len(l.Elements), len(l.Elements))
+			for idx, element := range l.Elements {
+				val, err := fromValue(element, t.Elem())
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("element %d: %v", idx, err)
+				}
+				rv.Index(idx).Set(val)
+			}
+			return rv, nil
+		}
+	case reflect.Map:
+		if m, ok := v.AsObject().(*LoxMap); ok {
+			rv = reflect.MakeMapWithSize(t, m.Len())
+			for _, key := range m.Keys() {
+				k, err := fromValue(key, t.Key())
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("key %v: %v", key, err)
+				}
+				val, _ := m.Get(key)
+				elem, err := fromValue(val, t.Elem())
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("value of %v: %v", key, err)
+				}
+				rv.SetMapIndex(k, elem)
+			}
+			return rv, nil
+		}
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			// lists and maps become their plain Go equivalent
+			switch v.AsObject().(type) {
+			case *LoxList:
+				return fromValue(v, goListType)
+			case *LoxMap:
+				return fromValue(v, goMapType)
+			}
+		}
+	}
+	if val := reflect.ValueOf(v.Interface()); val.Type().AssignableTo(t) {
+		return val, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %v to %s", v, t)
+}
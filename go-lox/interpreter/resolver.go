@@ -1,83 +1,360 @@
 package interpreter
 
-//
-// import (
-// 	"fmt"
-//
-// 	"github.com/vn-ki/go-lox/ast"
-// )
-//
-// type Scope map[string]bool
-//
-// func newScope() Scope { return make(Scope) }
-//
-// type Stack struct {
-// 	stack []Scope
-// }
-//
-// func (s *Stack) Push(scope Scope) {
-// 	s.stack = append(s.stack, scope)
-// }
-//
-// func (s *Stack) Pop() (Scope, bool) {
-// 	ret := s.Head()
-// 	if ret == nil {
-// 		return nil, false
-// 	}
-// 	last := len(s.stack) - 1
-// 	s.stack = s.stack[last:]
-// 	return ret, true
-// }
-//
-// func (s *Stack) Head() Scope {
-// 	last := len(s.stack) - 1
-// 	if last < 0 {
-// 		return nil
-// 	}
-// 	return s.stack[last]
-// }
-//
-// // Resolver
-//
-// type Resolver struct {
-// 	scopes Stack
-// 	i      *Interpreter
-// }
-//
-// func (r *Resolver) VisitBlock(s ast.Sblock) interface{} {
-// 	r.beginScope()
-//
-// 	for _, stmt := range s.Stmts {
-// 		r.resolveStmt(stmt)
-// 	}
-//
-// 	r.endScope()
-// 	return nil
-// }
-//
-// func (r *Resolver) resolveStmt(s ast.Stmt) {
-// 	s.Accept(r)
-// }
-//
-// func (r *Resolver) resolveExpr(e ast.Expr) {
-// 	e.Accept(r)
-// }
-//
-// func (r *Resolver) beginScope() {
-// 	r.scopes.Push(newScope())
-// }
-//
-// func (r *Resolver) endScope() {
-// 	r.scopes.Pop()
-// }
-//
-// func (r *Resolver) VisitVar(s ast.Svar) interface{} {
-// 	r.declare(s.Name)
-//
-// 	if s.Expression != nil {
-// 		r.resolveExpr(s.Expression)
-// 	}
-//
-// 	r.define(s.Name)
-// 	return nil
-// }
+import (
+	"fmt"
+
+	"github.com/vn-ki/go-lox/ast"
+	"github.com/vn-ki/go-lox/token"
+)
+
+// Scope maps the names declared in a scope to whether they are constants
+type Scope map[string]bool
+
+func newScope() Scope { return make(Scope) }
+
+type Stack struct {
+	stack []Scope
+}
+
+func (s *Stack) Push(scope Scope) {
+	s.stack = append(s.stack, scope)
+}
+
+func (s *Stack) Pop() (Scope, bool) {
+	ret := s.Head()
+	if ret == nil {
+		return nil, false
+	}
+	s.stack = s.stack[:len(s.stack)-1]
+	return ret, true
+}
+
+func (s *Stack) Head() Scope {
+	last := len(s.stack) - 1
+	if last < 0 {
+		return nil
+	}
+	return s.stack[last]
+}
+
+// Resolver
+
+// Resolver statically checks a program before it is interpreted. It reports
+// assignments to constants. Globals declared outside of the program being
+// resolved (like in an earlier REPL line) are checked at runtime instead.
+type Resolver struct {
+	ErrorHandler func(token token.Token, msg string)
+	// the first scope is the global scope
+	scopes Stack
+	// the number of functions being resolved, to check that return is in one
+	functionDepth int
+	hadError      bool
+}
+
+func NewResolver() *Resolver {
+	r := &Resolver{}
+	r.beginScope()
+	return r
+}
+
+// Resolve checks stmts and reports whether an error occured
+func (r *Resolver) Resolve(stmts []ast.Stmt) bool {
+	r.resolveStmts(stmts)
+	return r.hadError
+}
+
+func (r *Resolver) resolveStmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		r.resolveStmt(stmt)
+	}
+}
+
+func (r *Resolver) resolveStmt(s ast.Stmt) {
+	s.Accept(r)
+}
+
+func (r *Resolver) resolveExpr(e ast.Expr) {
+	e.Accept(r)
+}
+
+func (r *Resolver) resolveExprs(exprs []ast.Expr) {
+	for _, expr := range exprs {
+		r.resolveExpr(expr)
+	}
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes.Push(newScope())
+}
+
+func (r *Resolver) endScope() {
+	r.scopes.Pop()
+}
+
+func (r *Resolver) declare(name token.Token, constant bool) {
+	scope := r.scopes.Head()
+	if scope[name.Lexeme] {
+		r.err(name, fmt.Sprintf("cannot redeclare constant '%s'", name.Lexeme))
+	}
+	scope[name.Lexeme] = constant
+}
+
+// checkAssign reports an assignment to target if it is a known constant
+func (r *Resolver) checkAssign(target ast.Expr) {
+	v, ok := target.(ast.Evariable)
+	if !ok {
+		return
+	}
+	for idx := len(r.scopes.stack) - 1; idx >= 0; idx-- {
+		if constant, ok := r.scopes.stack[idx][v.Name.Lexeme]; ok {
+			if constant {
+				r.err(v.Name, fmt.Sprintf("cannot assign to constant '%s'", v.Name.Lexeme))
+			}
+			return
+		}
+	}
+}
+
+func (r *Resolver) err(tok token.Token, msg string) {
+	r.hadError = true
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(tok, msg)
+	}
+}
+
+// Statements
+
+func (r *Resolver) VisitBlock(s ast.Sblock) interface{} {
+	r.beginScope()
+	r.resolveStmts(s.Stmts)
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitVar(s ast.Svar) interface{} {
+	if s.Expression != nil {
+		r.resolveExpr(s.Expression)
+	}
+	r.declare(s.Name, s.Const)
+	return nil
+}
+
+func (r *Resolver) VisitDestructure(s ast.Sdestructure) interface{} {
+	r.resolveExpr(s.Expression)
+	for _, name := range ast.PatternBindings(s.Pattern) {
+		r.declare(name, s.Const)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitImport(s ast.Simport) interface{} {
+	r.declare(s.Name, false)
+	return nil
+}
+
+func (r *Resolver) VisitExport(s ast.Sexport) interface{} {
+	if len(r.scopes.stack) != 1 {
+		r.err(s.Keyword, "export is only allowed at the top level")
+	}
+	r.resolveStmt(s.Declaration)
+	return nil
+}
+
+func (r *Resolver) VisitFunction(s ast.Sfunction) interface{} {
+	r.declare(s.Name, false)
+
+	r.beginScope()
+	for idx, param := range s.Params {
+		if s.Defaults[idx] != nil {
+			r.resolveExpr(s.Defaults[idx])
+		}
+		r.declare(param, false)
+	}
+	if s.Rest != nil {
+		r.declare(*s.Rest, false)
+	}
+	r.functionDepth++
+	r.resolveStmts(s.Body)
+	r.functionDepth--
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitExpression(s ast.Sexpression) interface{} {
+	r.resolveExpr(s.Expression)
+	return nil
+}
+
+func (r *Resolver) VisitPrint(s ast.Sprint) interface{} {
+	r.resolveExpr(s.Expression)
+	return nil
+}
+
+func (r *Resolver) VisitIf(s ast.Sif) interface{} {
+	r.resolveExpr(s.Condition)
+	r.resolveStmt(s.ThenBranch)
+	if s.ElseBranch != nil {
+		r.resolveStmt(s.ElseBranch)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitWhile(s ast.Swhile) interface{} {
+	if s.Condition != nil {
+		r.resolveExpr(s.Condition)
+	}
+	r.resolveStmt(s.Body)
+	return nil
+}
+
+func (r *Resolver) VisitReturn(s ast.Sreturn) interface{} {
+	if r.functionDepth == 0 {
+		r.err(s.Keyword, "return is only allowed inside a function")
+	}
+	if s.Value != nil {
+		r.resolveExpr(s.Value)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitThrow(s ast.Sthrow) interface{} {
+	r.resolveExpr(s.Value)
+	return nil
+}
+
+func (r *Resolver) VisitTry(s ast.Stry) interface{} {
+	r.VisitBlock(ast.Sblock{Stmts: s.Body})
+	if s.CatchName != nil {
+		r.beginScope()
+		r.declare(*s.CatchName, false)
+		r.resolveStmts(s.CatchBody)
+		r.endScope()
+	}
+	if s.FinallyBody != nil {
+		r.VisitBlock(ast.Sblock{Stmts: s.FinallyBody})
+	}
+	return nil
+}
+
+func (r *Resolver) VisitMatch(s ast.Smatch) interface{} {
+	r.resolveExpr(s.Subject)
+	for _, c := range s.Cases {
+		r.beginScope()
+		for _, pattern := range c.Patterns {
+			for _, name := range ast.PatternBindings(pattern) {
+				r.declare(name, false)
+			}
+		}
+		r.resolveStmt(c.Body)
+		r.endScope()
+	}
+	if s.Default != nil {
+		r.beginScope()
+		r.resolveStmt(s.Default)
+		r.endScope()
+	}
+	return nil
+}
+
+// Expressions
+
+func (r *Resolver) VisitAssign(e ast.Eassign) interface{} {
+	r.resolveExpr(e.Value)
+	r.checkAssign(ast.Evariable{Name: e.Name})
+	return nil
+}
+
+func (r *Resolver) VisitCompound(e ast.Ecompound) interface{} {
+	r.resolveExpr(e.Target)
+	r.resolveExpr(e.Value)
+	r.checkAssign(e.Target)
+	return nil
+}
+
+func (r *Resolver) VisitIncrement(e ast.Eincrement) interface{} {
+	r.resolveExpr(e.Target)
+	r.checkAssign(e.Target)
+	return nil
+}
+
+func (r *Resolver) VisitVariable(e ast.Evariable) interface{} { return nil }
+
+func (r *Resolver) VisitLiteral(e ast.Literal) interface{} { return nil }
+
+func (r *Resolver) VisitBinary(e ast.Binary) interface{} {
+	r.resolveExpr(e.Left)
+	r.resolveExpr(e.Right)
+	return nil
+}
+
+func (r *Resolver) VisitLogical(e ast.Elogical) interface{} {
+	r.resolveExpr(e.Left)
+	r.resolveExpr(e.Right)
+	return nil
+}
+
+func (r *Resolver) VisitUnary(e ast.Unary) interface{} {
+	r.resolveExpr(e.Right)
+	return nil
+}
+
+func (r *Resolver) VisitGrouping(e ast.Grouping) interface{} {
+	r.resolveExpr(e.Expression)
+	return nil
+}
+
+func (r *Resolver) VisitCall(e ast.Ecall) interface{} {
+	r.resolveExpr(e.Callee)
+	r.resolveExprs(e.Args)
+	for _, arg := range e.Named {
+		r.resolveExpr(arg.Value)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitInterpolation(e ast.Einterpolation) interface{} {
+	r.resolveExprs(e.Parts)
+	return nil
+}
+
+func (r *Resolver) VisitGet(e ast.Eget) interface{} {
+	r.resolveExpr(e.Object)
+	return nil
+}
+
+func (r *Resolver) VisitSet(e ast.Eset) interface{} {
+	r.resolveExpr(e.Object)
+	r.resolveExpr(e.Value)
+	return nil
+}
+
+func (r *Resolver) VisitConditional(e ast.Econditional) interface{} {
+	r.resolveExpr(e.Condition)
+	r.resolveExpr(e.ThenBranch)
+	r.resolveExpr(e.ElseBranch)
+	return nil
+}
+
+func (r *Resolver) VisitList(e ast.Elist) interface{} {
+	r.resolveExprs(e.Elements)
+	return nil
+}
+
+func (r *Resolver) VisitIndex(e ast.Eindex) interface{} {
+	r.resolveExpr(e.Object)
+	r.resolveExpr(e.Index)
+	return nil
+}
+
+func (r *Resolver) VisitIndexSet(e ast.EindexSet) interface{} {
+	r.resolveExpr(e.Object)
+	r.resolveExpr(e.Index)
+	r.resolveExpr(e.Value)
+	return nil
+}
+
+func (r *Resolver) VisitMap(e ast.Emap) interface{} {
+	r.resolveExprs(e.Keys)
+	r.resolveExprs(e.Values)
+	return nil
+}
@@ -0,0 +1,324 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vn-ki/go-lox/env"
+	"github.com/vn-ki/go-lox/lexer"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// the natives which map a string to a string
+var stringFunctions = map[string]func(string) string{
+	"trim":  strings.TrimSpace,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// defineStrings defines the string natives in a global environment. Strings
+// are indexed by rune, so `len("héllo")` is 5 and `charAt("héllo", 1)` is
+// "é".
+func (i *Interpreter) defineStrings(globals *env.Environemnt) {
+	for name, fn := range stringFunctions {
+		name, fn := name, fn
+		globals.Define(name, newNative(name, 1, func(args []Value) (Value, error) {
+			s, err := stringArg(name, args, 0)
+			if err != nil {
+				return value.Nil, err
+			}
+			return value.NewString(fn(s)), nil
+		}))
+	}
+
+	globals.Define("len", newNative("len", 1, func(args []Value) (Value, error) {
+		if args[0].IsString() {
+			return value.NewNumber(float64(utf8.RuneCountInString(args[0].AsString()))), nil
+		}
+		switch o := args[0].AsObject().(type) {
+		case *LoxList:
+			return value.NewNumber(float64(len(o.Elements))), nil
+		case *LoxMap:
+			return value.NewNumber(float64(o.Len())), nil
+		}
+		return value.Nil, fmt.Errorf("len: argument 1 must be a string, list or map, got %s", args[0].TypeName())
+	}))
+
+	globals.Define("substring", newNativeRange("substring", 2, 3, func(args []Value) (Value, error) {
+		s, err := stringArg("substring", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		runes := []rune(s)
+		start, end, err := rangeArgs("substring", args, len(runes))
+		if err != nil {
+			return value.Nil, err
+		}
+		if start < 0 || start > len(runes) {
+			return value.Nil, fmt.Errorf("substring: start %d is out of range for length %d", start, len(runes))
+		}
+		if end < start || end > len(runes) {
+			return value.Nil, fmt.Errorf("substring: end %d is out of range for start %d and length %d", end, start, len(runes))
+		}
+		return value.NewString(string(runes[start:end])), nil
+	}))
+	globals.Define("slice", newNativeRange("slice", 2, 3, func(args []Value) (Value, error) {
+		if args[0].IsString() {
+			runes := []rune(args[0].AsString())
+			start, end, err := sliceArgs(args, len(runes))
+			if err != nil {
+				return value.Nil, err
+			}
+			return value.NewString(string(runes[start:end])), nil
+		}
+		if list, ok := args[0].AsObject().(*LoxList); ok {
+			start, end, err := sliceArgs(args, len(list.Elements))
+			if err != nil {
+				return value.Nil, err
+			}
+			elements := make([]Value, end-start)
+			copy(elements, list.Elements[start:end])
+			return value.NewObject(NewLoxList(elements)), nil
+		}
+		return value.Nil, fmt.Errorf("slice: argument 1 must be a string or list, got %s", args[0].TypeName())
+	}))
+	globals.Define("charAt", newNative("charAt", 2, func(args []Value) (Value, error) {
+		s, err := stringArg("charAt", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		idx, err := integerArg("charAt", args, 1)
+		if err != nil {
+			return value.Nil, err
+		}
+		runes := []rune(s)
+		if idx < 0 || idx >= int64(len(runes)) {
+			return value.Nil, fmt.Errorf("charAt: index %d is out of range for length %d", idx, len(runes))
+		}
+		return value.NewString(string(runes[idx])), nil
+	}))
+
+	globals.Define("indexOf", newNativeRange("indexOf", 2, 3, func(args []Value) (Value, error) {
+		from := int64(0)
+		if len(args) == 3 {
+			var err error
+			if from, err = integerArg("indexOf", args, 2); err != nil {
+				return value.Nil, err
+			}
+			if from < 0 {
+				from = 0
+			}
+		}
+		if args[0].IsString() {
+			sub, err := stringArg("indexOf", args, 1)
+			if err != nil {
+				return value.Nil, err
+			}
+			return value.NewNumber(float64(runeIndex(args[0].AsString(), sub, from))), nil
+		}
+		if list, ok := args[0].AsObject().(*LoxList); ok {
+			for idx := from; idx < int64(len(list.Elements)); idx++ {
+				if list.Elements[idx].Equals(args[1]) {
+					return value.NewNumber(float64(idx)), nil
+				}
+			}
+			return value.NewNumber(-1), nil
+		}
+		return value.Nil, fmt.Errorf("indexOf: argument 1 must be a string or list, got %s", args[0].TypeName())
+	}))
+	globals.Define("startsWith", newNative("startsWith", 2, func(args []Value) (Value, error) {
+		s, prefix, err := stringArgs("startsWith", args)
+		if err != nil {
+			return value.Nil, err
+		}
+		return value.NewBool(strings.HasPrefix(s, prefix)), nil
+	}))
+	globals.Define("endsWith", newNative("endsWith", 2, func(args []Value) (Value, error) {
+		s, suffix, err := stringArgs("endsWith", args)
+		if err != nil {
+			return value.Nil, err
+		}
+		return value.NewBool(strings.HasSuffix(s, suffix)), nil
+	}))
+	globals.Define("replace", newNative("replace", 3, func(args []Value) (Value, error) {
+		s, old, err := stringArgs("replace", args)
+		if err != nil {
+			return value.Nil, err
+		}
+		replacement, err := stringArg("replace", args, 2)
+		if err != nil {
+			return value.Nil, err
+		}
+		return value.NewString(strings.ReplaceAll(s, old, replacement)), nil
+	}))
+
+	globals.Define("split", newNative("split", 2, func(args []Value) (Value, error) {
+		s, sep, err := stringArgs("split", args)
+		if err != nil {
+			return value.Nil, err
+		}
+		parts := strings.Split(s, sep)
+		elements := make([]Value, len(parts))
+		for idx, part := range parts {
+			elements[idx] = value.NewString(part)
+		}
+		return value.NewObject(NewLoxList(elements)), nil
+	}))
+	globals.Define("join", newNative("join", 2, func(args []Value) (Value, error) {
+		list, ok := args[0].AsObject().(*LoxList)
+		if !ok {
+			return value.Nil, fmt.Errorf("join: argument 1 must be a list, got %s", args[0].TypeName())
+		}
+		sep, err := stringArg("join", args, 1)
+		if err != nil {
+			return value.Nil, err
+		}
+		parts := make([]string, len(list.Elements))
+		for idx, element := range list.Elements {
+			parts[idx] = element.String()
+		}
+		return value.NewString(strings.Join(parts, sep)), nil
+	}))
+
+	globals.Define("ord", newNative("ord", 1, func(args []Value) (Value, error) {
+		s, err := stringArg("ord", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 || size != len(s) {
+			return value.Nil, fmt.Errorf("ord: argument 1 must be a single character, got %q", s)
+		}
+		return value.NewNumber(float64(r)), nil
+	}))
+	globals.Define("chr", newNative("chr", 1, func(args []Value) (Value, error) {
+		n, err := integerArg("chr", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		if n > utf8.MaxRune || !utf8.ValidRune(rune(n)) {
+			return value.Nil, fmt.Errorf("chr: %d is not a valid code point", n)
+		}
+		return value.NewString(string(rune(n))), nil
+	}))
+
+	globals.Define("toNumber", newNative("toNumber", 1, func(args []Value) (Value, error) {
+		if args[0].IsNumber() {
+			return args[0], nil
+		}
+		s, err := stringArg("toNumber", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		// numbers are parsed like number literals, with an optional sign
+		s = strings.TrimSpace(s)
+		sign := 1.0
+		if strings.HasPrefix(s, "-") {
+			s, sign = s[1:], -1
+		}
+		n, ok := lexer.ParseNumber(s)
+		if !ok {
+			return value.Nil, nil
+		}
+		return value.NewNumber(sign * n), nil
+	}))
+	globals.Define("toString", newNativeRange("toString", 1, 2, func(args []Value) (Value, error) {
+		if len(args) == 1 {
+			return value.NewString(args[0].String()), nil
+		}
+		n, err := numberArg("toString", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		precision, err := integerArg("toString", args, 1)
+		if err != nil {
+			return value.Nil, err
+		}
+		if precision < 0 || precision > 100 {
+			return value.Nil, fmt.Errorf("toString: precision must be between 0 and 100, got %d", precision)
+		}
+		return value.NewString(strconv.FormatFloat(n, 'f', int(precision), 64)), nil
+	}))
+}
+
+// stringArg checks that the argument at idx of the native name is a string
+// and returns it
+func stringArg(name string, args []Value, idx int) (string, error) {
+	if !args[idx].IsString() {
+		return "", fmt.Errorf("%s: argument %d must be a string, got %s", name, idx+1, args[idx].TypeName())
+	}
+	return args[idx].AsString(), nil
+}
+
+// stringArgs checks that the first two arguments of the native name are
+// strings and returns them
+func stringArgs(name string, args []Value) (string, string, error) {
+	a, err := stringArg(name, args, 0)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := stringArg(name, args, 1)
+	return a, b, err
+}
+
+// rangeArgs returns the start and optional end arguments of the native
+// name. end defaults to length.
+func rangeArgs(name string, args []Value, length int) (int, int, error) {
+	start, err := integerArg(name, args, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	end := int64(length)
+	if len(args) == 3 {
+		if end, err = integerArg(name, args, 2); err != nil {
+			return 0, 0, err
+		}
+	}
+	return int(start), int(end), nil
+}
+
+// sliceArgs returns the range of slice. Negative indexes count from the end,
+// and indexes past either end are clamped, so slice never fails on a range.
+func sliceArgs(args []Value, length int) (int, int, error) {
+	start, end, err := rangeArgs("slice", args, length)
+	if err != nil {
+		return 0, 0, err
+	}
+	clamp := func(idx int) int {
+		if idx < 0 {
+			idx += length
+		}
+		if idx < 0 {
+			return 0
+		}
+		if idx > length {
+			return length
+		}
+		return idx
+	}
+	start, end = clamp(start), clamp(end)
+	if end < start {
+		end = start
+	}
+	return start, end, nil
+}
+
+// runeIndex returns the rune index of the first sub in s at or after the
+// rune index from, or -1 if there isn't one
+func runeIndex(s, sub string, from int64) int64 {
+	offset := 0
+	for idx := int64(0); idx < from; idx++ {
+		if offset >= len(s) {
+			return -1
+		}
+		_, size := utf8.DecodeRuneInString(s[offset:])
+		offset += size
+	}
+	found := strings.Index(s[offset:], sub)
+	if found < 0 {
+		return -1
+	}
+	return from + int64(utf8.RuneCountInString(s[offset:offset+found]))
+}
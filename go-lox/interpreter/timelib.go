@@ -0,0 +1,201 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vn-ki/go-lox/env"
+	"github.com/vn-ki/go-lox/value"
+)
+
+// Clock is the source of time for clock(), now() and sleep(). Tests can set
+// a fake clock with SetClock to run scripts deterministically.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// systemClock is the real clock, the default of NewInterpreter
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// SetClock sets the clock used by the time natives
+func (i *Interpreter) SetClock(c Clock) {
+	i.clock = c
+}
+
+// defaultTimeFormat is the format of formatTime and parseTime when none is
+// given, ISO 8601 with milliseconds
+const defaultTimeFormat = "%Y-%m-%dT%H:%M:%S.%LZ"
+
+// the width of the fields of time formats, by directive
+var timeFields = map[byte]int{'Y': 4, 'm': 2, 'd': 2, 'H': 2, 'M': 2, 'S': 2, 'L': 3}
+
+// defineTime defines the time natives in a global environment. Times are
+// numbers of milliseconds since the Unix epoch and are formatted in UTC.
+func (i *Interpreter) defineTime(globals *env.Environemnt) {
+	globals.Define("now", newNative("now", 0, func(args []Value) (Value, error) {
+		return value.NewNumber(float64(i.clock.Now().UnixNano() / int64(time.Millisecond))), nil
+	}))
+	globals.Define("sleep", newNative("sleep", 1, func(args []Value) (Value, error) {
+		d, err := millisecondsArg("sleep", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		if d < 0 {
+			return value.Nil, fmt.Errorf("sleep: duration must not be negative, got %v", args[0])
+		}
+		i.clock.Sleep(d)
+		return value.Nil, nil
+	}))
+	globals.Define("formatTime", newNativeRange("formatTime", 1, 2, func(args []Value) (Value, error) {
+		sinceEpoch, err := millisecondsArg("formatTime", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		format, err := timeFormatArg("formatTime", args)
+		if err != nil {
+			return value.Nil, err
+		}
+		t := time.Unix(0, int64(sinceEpoch)).UTC()
+		s, err := formatTime(t, format)
+		if err != nil {
+			return value.Nil, fmt.Errorf("formatTime: %v", err)
+		}
+		return value.NewString(s), nil
+	}))
+	globals.Define("parseTime", newNativeRange("parseTime", 1, 2, func(args []Value) (Value, error) {
+		s, err := stringArg("parseTime", args, 0)
+		if err != nil {
+			return value.Nil, err
+		}
+		format, err := timeFormatArg("parseTime", args)
+		if err != nil {
+			return value.Nil, err
+		}
+		t, err := parseTime(s, format)
+		if err != nil {
+			return value.Nil, fmt.Errorf("parseTime: %v", err)
+		}
+		return value.NewNumber(float64(t.UnixNano() / int64(time.Millisecond))), nil
+	}))
+}
+
+// maxMilliseconds is the largest number of milliseconds which fits in a
+// time.Duration, about 292 years
+const maxMilliseconds = math.MaxInt64 / int64(time.Millisecond)
+
+// millisecondsArg checks that the argument at idx of the native name is a
+// number of milliseconds which fits in a time.Duration and returns it
+func millisecondsArg(name string, args []Value, idx int) (time.Duration, error) {
+	ms, err := numberArg(name, args, idx)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(ms) || math.IsInf(ms, 0) {
+		return 0, fmt.Errorf("%s: argument %d must be a finite number, got %v", name, idx+1, args[idx])
+	}
+	if math.Abs(ms) > float64(maxMilliseconds) {
+		return 0, fmt.Errorf("%s: %v milliseconds is out of range", name, args[idx])
+	}
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
+
+// timeFormatArg returns the optional format argument of the native name
+func timeFormatArg(name string, args []Value) (string, error) {
+	if len(args) < 2 {
+		return defaultTimeFormat, nil
+	}
+	return stringArg(name, args, 1)
+}
+
+// formatTime formats t with format. `%Y %m %d %H %M %S` are the year, month,
+// day, hours, minutes and seconds, `%L` the milliseconds and `%%` is a `%`.
+func formatTime(t time.Time, format string) (string, error) {
+	var sb strings.Builder
+	for idx := 0; idx < len(format); idx++ {
+		if format[idx] != '%' {
+			sb.WriteByte(format[idx])
+			continue
+		}
+		if idx++; idx == len(format) {
+			return "", fmt.Errorf("format ends with a lone %%")
+		}
+		var field int
+		switch format[idx] {
+		case '%':
+			sb.WriteByte('%')
+			continue
+		case 'Y':
+			field = t.Year()
+		case 'm':
+			field = int(t.Month())
+		case 'd':
+			field = t.Day()
+		case 'H':
+			field = t.Hour()
+		case 'M':
+			field = t.Minute()
+		case 'S':
+			field = t.Second()
+		case 'L':
+			field = t.Nanosecond() / int(time.Millisecond)
+		default:
+			return "", fmt.Errorf("unknown directive %%%c", format[idx])
+		}
+		fmt.Fprintf(&sb, "%0*d", timeFields[format[idx]], field)
+	}
+	return sb.String(), nil
+}
+
+// parseTime parses s, which must match format exactly. The fields that
+// format leaves out are the start of their range, like January or midnight.
+func parseTime(s string, format string) (time.Time, error) {
+	fields := map[byte]int{'Y': 1970, 'm': 1, 'd': 1}
+	pos := 0
+	for idx := 0; idx < len(format); idx++ {
+		c := format[idx]
+		if c == '%' {
+			if idx++; idx == len(format) {
+				return time.Time{}, fmt.Errorf("format ends with a lone %%")
+			}
+			c = format[idx]
+			if width, ok := timeFields[c]; ok {
+				if pos+width > len(s) {
+					return time.Time{}, fmt.Errorf("%q is too short for %q", s, format)
+				}
+				n, err := strconv.Atoi(s[pos : pos+width])
+				if err != nil || strings.ContainsAny(s[pos:pos+width], "+-") {
+					return time.Time{}, fmt.Errorf("%q is not a %%%c field at offset %d", s[pos:pos+width], c, pos)
+				}
+				fields[c] = n
+				pos += width
+				continue
+			}
+			if c != '%' {
+				return time.Time{}, fmt.Errorf("unknown directive %%%c", c)
+			}
+		}
+		if pos >= len(s) || s[pos] != c {
+			return time.Time{}, fmt.Errorf("%q does not match %q at offset %d", s, format, pos)
+		}
+		pos++
+	}
+	if pos != len(s) {
+		return time.Time{}, fmt.Errorf("unexpected %q after the time", s[pos:])
+	}
+
+	t := time.Date(fields['Y'], time.Month(fields['m']), fields['d'],
+		fields['H'], fields['M'], fields['S'], fields['L']*int(time.Millisecond), time.UTC)
+	// time.Date normalizes out of range fields, so February 30 would be in March
+	if int(t.Month()) != fields['m'] || t.Day() != fields['d'] || t.Hour() != fields['H'] ||
+		t.Minute() != fields['M'] || t.Second() != fields['S'] {
+		return time.Time{}, fmt.Errorf("%q is not a valid time", s)
+	}
+	return t, nil
+}
@@ -1,30 +1,44 @@
 package lexer
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/vn-ki/go-lox/token"
 )
 
 var KEYWORDS = map[string]token.TokenType{
-	"and":    token.Tand,
-	"class":  token.Tclass,
-	"else":   token.Telse,
-	"false":  token.Tfalse,
-	"for":    token.Tfor,
-	"fun":    token.Tfun,
-	"if":     token.Tif,
-	"nil":    token.Tnil,
-	"or":     token.Tor,
-	"print":  token.Tprint,
-	"return": token.Treturn,
-	"super":  token.Tsuper,
-	"this":   token.Tsuper,
-	"true":   token.Ttrue,
-	"var":    token.Tvar,
-	"while":  token.Twhile,
+	"and":     token.Tand,
+	"class":   token.Tclass,
+	"else":    token.Telse,
+	"false":   token.Tfalse,
+	"for":     token.Tfor,
+	"fun":     token.Tfun,
+	"if":      token.Tif,
+	"nil":     token.Tnil,
+	"or":      token.Tor,
+	"print":   token.Tprint,
+	"return":  token.Treturn,
+	"super":   token.Tsuper,
+	"this":    token.Tsuper,
+	"true":    token.Ttrue,
+	"var":     token.Tvar,
+	"while":   token.Twhile,
+	"throw":   token.Tthrow,
+	"try":     token.Ttry,
+	"catch":   token.Tcatch,
+	"finally": token.Tfinally,
+	"const":   token.Tconst,
+	"match":   token.Tmatch,
+	"case":    token.Tcase,
+	"default": token.Tdefault,
+	"import":  token.Timport,
+	"export":  token.Texport,
 }
 
 type Lexer struct {
@@ -34,8 +48,12 @@ type Lexer struct {
 	src     []rune
 	// This could be a channel in the most golang-y way
 	// But following crafting interpreters closely here
-	tokens       []token.Token
-	ErrorHandler func(int, string)
+	tokens []token.Token
+	// brace depth of each string interpolation we are currently inside
+	interpolations []int
+	ErrorHandler   func(int, string)
+	// quiet turns off the debug logging, for lexing at runtime
+	quiet bool
 }
 
 // TODO: use reader instead of string here
@@ -44,7 +62,9 @@ func NewLexer(src string) *Lexer {
 		0, 0, 1,
 		[]rune(src),
 		make([]token.Token, 0),
+		make([]int, 0),
 		nil,
+		false,
 	}
 }
 
@@ -53,7 +73,10 @@ func (l *Lexer) ScanTokens() []token.Token {
 		l.start = l.current
 		l.scanToken()
 	}
-	l.tokens = append(l.tokens, token.Token{token.Teof, "", nil, l.line})
+	if len(l.interpolations) != 0 {
+		l.err("Unterminated string interpolation")
+	}
+	l.tokens = append(l.tokens, token.Token{Type: token.Teof, Lexeme: "", Literal: nil, Line: l.line})
 	return l.tokens
 }
 
@@ -66,21 +89,83 @@ func (l *Lexer) scanToken() {
 	case ')':
 		l.addToken(token.TrightParen)
 	case '{':
+		if depth := len(l.interpolations); depth != 0 {
+			l.interpolations[depth-1]++
+		}
 		l.addToken(token.TleftBrace)
 	case '}':
+		if depth := len(l.interpolations); depth != 0 {
+			if l.interpolations[depth-1] == 0 {
+				// end of the interpolated expression, back to the string
+				l.interpolations = l.interpolations[:depth-1]
+				l.parseString()
+				return
+			}
+			l.interpolations[depth-1]--
+		}
 		l.addToken(token.TrightBrace)
+	case '[':
+		l.addToken(token.TleftBracket)
+	case ']':
+		l.addToken(token.TrightBracket)
 	case ',':
 		l.addToken(token.Tcomma)
 	case '.':
-		l.addToken(token.Tdot)
+		if l.peek() == '.' && l.peekNext() == '.' {
+			l.advance()
+			l.advance()
+			l.addToken(token.Tellipsis)
+		} else {
+			l.addToken(token.Tdot)
+		}
 	case '-':
-		l.addToken(token.Tminus)
+		if l.match('=') {
+			l.addToken(token.TminusEqual)
+		} else if l.match('-') {
+			l.addToken(token.TminusMinus)
+		} else {
+			l.addToken(token.Tminus)
+		}
 	case '+':
-		l.addToken(token.Tplus)
+		if l.match('=') {
+			l.addToken(token.TplusEqual)
+		} else if l.match('+') {
+			l.addToken(token.TplusPlus)
+		} else {
+			l.addToken(token.Tplus)
+		}
 	case ';':
 		l.addToken(token.Tsemicolon)
 	case '*':
-		l.addToken(token.Tstar)
+		if l.match('*') {
+			l.addToken(token.TstarStar)
+		} else if l.match('=') {
+			l.addToken(token.TstarEqual)
+		} else {
+			l.addToken(token.Tstar)
+		}
+	case '%':
+		l.addToken(token.Tpercent)
+	case ':':
+		l.addToken(token.Tcolon)
+	case '?':
+		if l.match('?') {
+			l.addToken(token.TquestionQuestion)
+		} else {
+			l.addToken(token.Tquestion)
+		}
+	case '&':
+		l.addToken(token.Tamp)
+	case '|':
+		l.addToken(token.Tpipe)
+	case '^':
+		l.addToken(token.Tcaret)
+	case '~':
+		if l.match('/') {
+			l.addToken(token.TtildeSlash)
+		} else {
+			l.addToken(token.Ttilde)
+		}
 
 	case '!':
 		if l.match('=') {
@@ -91,18 +176,24 @@ func (l *Lexer) scanToken() {
 	case '=':
 		if l.match('=') {
 			l.addToken(token.TequalEqual)
+		} else if l.match('>') {
+			l.addToken(token.TequalGreater)
 		} else {
 			l.addToken(token.Tequal)
 		}
 	case '<':
 		if l.match('=') {
 			l.addToken(token.TlessEqual)
+		} else if l.match('<') {
+			l.addToken(token.TlessLess)
 		} else {
 			l.addToken(token.Tless)
 		}
 	case '>':
 		if l.match('=') {
 			l.addToken(token.TgreaterEqual)
+		} else if l.match('>') {
+			l.addToken(token.TgreaterGreater)
 		} else {
 			l.addToken(token.Tgreater)
 		}
@@ -112,6 +203,10 @@ func (l *Lexer) scanToken() {
 			for l.peek() != '\n' && !l.isAtEnd() {
 				l.advance()
 			}
+		} else if l.match('*') {
+			l.blockComment()
+		} else if l.match('=') {
+			l.addToken(token.TslashEqual)
 		} else {
 			l.addToken(token.Tslash)
 		}
@@ -128,9 +223,9 @@ func (l *Lexer) scanToken() {
 		l.parseString()
 
 	default:
-		if unicode.IsDigit(c) {
+		if isDigit(c) {
 			l.parseNum()
-		} else if unicode.IsLetter(c) {
+		} else if isIdentStart(c) {
 			l.parseIden()
 		} else {
 			l.err("Unexpected character")
@@ -138,8 +233,29 @@ func (l *Lexer) scanToken() {
 	}
 }
 
+// blockComment skips a `/* ... */` comment. Block comments may nest.
+func (l *Lexer) blockComment() {
+	startLine := l.line
+	depth := 1
+	for depth > 0 {
+		if l.isAtEnd() {
+			l.errAt(startLine, "Unterminated block comment")
+			return
+		}
+		c := l.advance()
+		switch {
+		case c == '\n':
+			l.line++
+		case c == '/' && l.match('*'):
+			depth++
+		case c == '*' && l.match('/'):
+			depth--
+		}
+	}
+}
+
 func (l *Lexer) parseIden() {
-	for unicode.IsLetter(l.peek()) {
+	for isIdentContinue(l.peek()) {
 		l.advance()
 	}
 	val := string(l.src[l.start:l.current])
@@ -152,11 +268,25 @@ func (l *Lexer) parseIden() {
 
 func (l *Lexer) parseString() {
 	log.Println("Parsing string")
+	var value strings.Builder
 	for l.peek() != '"' && !l.isAtEnd() {
-		if l.peek() == '\n' {
+		c := l.advance()
+		switch c {
+		case '\n':
 			l.line++
+		case '\\':
+			if r, ok := l.parseEscape(); ok {
+				value.WriteRune(r)
+			}
+			continue
+		case '$':
+			if l.match('{') {
+				l.addTokenWithLiteral(token.Tinterpolation, value.String())
+				l.interpolations = append(l.interpolations, 0)
+				return
+			}
 		}
-		l.advance()
+		value.WriteRune(c)
 	}
 
 	if l.isAtEnd() {
@@ -167,30 +297,175 @@ func (l *Lexer) parseString() {
 	// last '"'
 	l.advance()
 
-	// trim surrounding quotes
-	value := string(l.src[l.start+1 : l.current-1])
-	l.addTokenWithLiteral(token.Tstring, value)
+	l.addTokenWithLiteral(token.Tstring, value.String())
 }
 
-func (l *Lexer) parseNum() {
-	log.Println("Parsing a number")
-	for unicode.IsDigit(l.peek()) {
+// parseEscape decodes the escape sequence following a backslash inside a
+// string. It reports a diagnostic and returns false if the sequence is invalid.
+func (l *Lexer) parseEscape() (rune, bool) {
+	if l.isAtEnd() {
+		return 0, false
+	}
+	c := l.advance()
+	switch c {
+	case 'n':
+		return '\n', true
+	case 't':
+		return '\t', true
+	case 'r':
+		return '\r', true
+	case '"':
+		return '"', true
+	case '\\':
+		return '\\', true
+	case '$':
+		return '$', true
+	case 'u':
+		return l.parseUnicodeEscape()
+	case '\n':
+		l.line++
+	}
+	l.err(fmt.Sprintf("Invalid escape sequence '\\%c'", c))
+	return 0, false
+}
+
+// parseUnicodeEscape decodes the `{XXXX}` part of a `\u{XXXX}` escape.
+func (l *Lexer) parseUnicodeEscape() (rune, bool) {
+	if !l.match('{') {
+		l.err("Expected '{' after '\\u'")
+		return 0, false
+	}
+	start := l.current
+	for isHexDigit(l.peek()) {
 		l.advance()
 	}
+	digits := string(l.src[start:l.current])
+	if !l.match('}') {
+		l.err("Expected '}' to close unicode escape")
+		return 0, false
+	}
+	if len(digits) == 0 || len(digits) > 6 {
+		l.err("Unicode escape must have 1 to 6 hex digits")
+		return 0, false
+	}
+	code, _ := strconv.ParseUint(digits, 16, 32)
+	r := rune(code)
+	if !utf8.ValidRune(r) {
+		l.err(fmt.Sprintf("Invalid unicode code point U+%X", code))
+		return 0, false
+	}
+	return r, true
+}
 
-	if l.peek() == '.' && unicode.IsDigit(l.peekNext()) {
+func (l *Lexer) parseNum() {
+	if !l.quiet {
+		log.Println("Parsing a number")
+	}
+	radix := 10
+	isRadixDigit := isDigit
+	if l.src[l.start] == '0' && (l.peek() == 'x' || l.peek() == 'X') {
+		radix, isRadixDigit = 16, isHexDigit
 		l.advance()
-		for unicode.IsDigit(l.peek()) {
+	} else if l.src[l.start] == '0' && (l.peek() == 'b' || l.peek() == 'B') {
+		radix, isRadixDigit = 2, isBinDigit
+		l.advance()
+	}
+	l.consumeDigits(isRadixDigit)
+
+	if radix == 10 {
+		if l.peek() == '.' && isDigit(l.peekNext()) {
 			l.advance()
+			l.consumeDigits(isDigit)
+		}
+		if l.peek() == 'e' || l.peek() == 'E' {
+			l.advance()
+			if l.peek() == '+' || l.peek() == '-' {
+				l.advance()
+			}
+			l.consumeDigits(isDigit)
 		}
 	}
-	val, err := strconv.ParseFloat(string(l.src[l.start:l.current]), 64)
+
+	// swallow the rest of things like `123abc` or `0b102` so that they are
+	// reported as a single malformed number
+	malformed := false
+	for isIdentContinue(l.peek()) {
+		malformed = true
+		l.advance()
+	}
+
+	text := string(l.src[l.start:l.current])
+	if malformed || !validSeparators(text, isRadixDigit) {
+		l.err("Malformed number: " + text)
+		l.addTokenWithLiteral(token.Tnumber, float64(0))
+		return
+	}
+
+	digits := strings.ReplaceAll(text, "_", "")
+	var val float64
+	var err error
+	if radix == 10 {
+		val, err = strconv.ParseFloat(digits, 64)
+	} else {
+		var n uint64
+		n, err = strconv.ParseUint(digits[2:], radix, 64)
+		val = float64(n)
+	}
 	if err != nil {
-		log.Fatalln("Couldnot parse number: " + string(l.src[l.start:l.current]))
+		if errors.Is(err, strconv.ErrRange) {
+			l.err("Number out of range: " + text)
+		} else {
+			l.err("Malformed number: " + text)
+		}
+		val = 0
 	}
 	l.addTokenWithLiteral(token.Tnumber, val)
 }
 
+// ParseNumber parses s as a number literal, like `42`, `0xFF`, `1e-9` or
+// `1_000`. ok is false if s is anything else, including surrounding spaces.
+// It scans only the number and doesn't log, so natives can call it at runtime.
+func ParseNumber(s string) (n float64, ok bool) {
+	l := NewLexer(s)
+	l.quiet = true
+	failed := false
+	l.ErrorHandler = func(line int, msg string) { failed = true }
+	if l.isAtEnd() || !isDigit(l.advance()) {
+		return 0, false
+	}
+	l.parseNum()
+	if failed || !l.isAtEnd() {
+		return 0, false
+	}
+	return l.tokens[0].Literal.(float64), true
+}
+
+// consumeDigits consumes a run of digits and '_' separators
+func (l *Lexer) consumeDigits(isRadixDigit func(rune) bool) {
+	for isRadixDigit(l.peek()) || l.peek() == '_' {
+		l.advance()
+	}
+}
+
+// validSeparators checks that every '_' in a number sits between two digits
+// and that the number does not end without digits (`0x`, `1e`).
+func validSeparators(text string, isRadixDigit func(rune) bool) bool {
+	runes := []rune(text)
+	last := runes[len(runes)-1]
+	if !isRadixDigit(last) {
+		return false
+	}
+	for idx, c := range runes {
+		if c != '_' {
+			continue
+		}
+		if idx == 0 || !isRadixDigit(runes[idx-1]) || !isRadixDigit(runes[idx+1]) {
+			return false
+		}
+	}
+	return true
+}
+
 func (l *Lexer) addToken(ty token.TokenType) {
 	// log.Printf()
 	l.addTokenWithLiteral(ty, nil)
@@ -198,7 +473,7 @@ func (l *Lexer) addToken(ty token.TokenType) {
 
 func (l *Lexer) addTokenWithLiteral(ty token.TokenType, literal interface{}) {
 	text := string(l.src[l.start:l.current])
-	l.tokens = append(l.tokens, token.Token{ty, text, literal, l.line})
+	l.tokens = append(l.tokens, token.Token{Type: ty, Lexeme: text, Literal: literal, Line: l.line})
 }
 
 func (l *Lexer) advance() rune {
@@ -233,10 +508,39 @@ func (l *Lexer) match(expected rune) bool {
 	return true
 }
 
+// isIdentStart follows the unicode ID_Start property, plus '_'
+func isIdentStart(c rune) bool {
+	return c == '_' || unicode.In(c, unicode.Letter, unicode.Nl, unicode.Other_ID_Start)
+}
+
+// isIdentContinue follows the unicode ID_Continue property
+func isIdentContinue(c rune) bool {
+	return isIdentStart(c) ||
+		unicode.In(c, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isBinDigit(c rune) bool {
+	return c == '0' || c == '1'
+}
+
+func isHexDigit(c rune) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
 func (l *Lexer) err(msg string) {
-	log.Print("!!! Error: " + msg)
+	l.errAt(l.line, msg)
+}
+
+func (l *Lexer) errAt(line int, msg string) {
+	if !l.quiet {
+		log.Print("!!! Error: " + msg)
+	}
 	if l.ErrorHandler != nil {
-		l.ErrorHandler(l.line, msg)
+		l.ErrorHandler(line, msg)
 	}
 }
 
@@ -0,0 +1,260 @@
+package lexer
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/vn-ki/go-lox/token"
+)
+
+type lexerDiagnostic struct {
+	line int
+	msg  string
+}
+
+func scan(src string) ([]token.Token, []lexerDiagnostic) {
+	errs := make([]lexerDiagnostic, 0)
+	lexer := NewLexer(src)
+	lexer.ErrorHandler = func(line int, msg string) {
+		errs = append(errs, lexerDiagnostic{line, msg})
+	}
+	return lexer.ScanTokens(), errs
+}
+
+func TestStringEscapes(t *testing.T) {
+	src := `"a\tb\nc\r\"d\"\\e\u{1F600}\u{41}"`
+	tokens, errs := scan(src)
+	if len(errs) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errs)
+	}
+	tok := tokens[0]
+	if tok.Type != token.Tstring {
+		t.Fatalf("Expected a string token, got: %v", tok)
+	}
+	expected := "a\tb\nc\r\"d\"\\e\U0001F600A"
+	if tok.Literal != expected {
+		t.Errorf("Expected: %q, got: %q", expected, tok.Literal)
+	}
+	if tok.Lexeme != src {
+		t.Errorf("Expected lexeme: %s, got: %s", src, tok.Lexeme)
+	}
+}
+
+func TestStringBadEscapes(t *testing.T) {
+	for _, src := range []string{
+		`"\q"`,
+		`"\u0041"`,
+		`"\u{}"`,
+		`"\u{1234567}"`,
+		`"\u{D800}"`,
+		`"\u{110000}"`,
+		`"\u{41"`,
+	} {
+		_, errs := scan(src)
+		if len(errs) != 1 {
+			t.Errorf("%s: expected one error, got: %v", src, errs)
+		}
+	}
+}
+
+func TestStringEscapedNewlineKeepsLine(t *testing.T) {
+	tokens, _ := scan(`"a\nb" x`)
+	if tokens[0].Literal != "a\nb" {
+		t.Errorf("Expected: %q, got: %q", "a\nb", tokens[0].Literal)
+	}
+	if tokens[1].Line != 1 {
+		t.Errorf("Expected line 1, got: %d", tokens[1].Line)
+	}
+}
+
+func TestStringMultilineCountsLines(t *testing.T) {
+	tokens, _ := scan("\"a\nb\" x")
+	if tokens[1].Line != 2 {
+		t.Errorf("Expected line 2, got: %d", tokens[1].Line)
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	tokens, errs := scan(`"Hello ${name + "${x}"}! \${y} {z}"`)
+	if len(errs) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errs)
+	}
+	expected := []token.TokenType{
+		token.Tinterpolation, token.Tidentifier, token.Tplus,
+		token.Tinterpolation, token.Tidentifier, token.Tstring,
+		token.Tstring, token.Teof,
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got: %v", len(expected), tokens)
+	}
+	for idx, ty := range expected {
+		if tokens[idx].Type != ty {
+			t.Errorf("token %d: expected %v, got: %v", idx, ty, tokens[idx])
+		}
+	}
+	if tokens[0].Literal != "Hello " {
+		t.Errorf("Expected: %q, got: %q", "Hello ", tokens[0].Literal)
+	}
+	if tokens[6].Literal != "! ${y} {z}" {
+		t.Errorf("Expected: %q, got: %q", "! ${y} {z}", tokens[6].Literal)
+	}
+}
+
+func TestStringInterpolationNestedBraces(t *testing.T) {
+	tokens, errs := scan(`"${f({})}"`)
+	if len(errs) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errs)
+	}
+	if last := tokens[len(tokens)-2]; last.Type != token.Tstring || last.Literal != "" {
+		t.Errorf("Expected closing string segment, got: %v", last)
+	}
+}
+
+func TestUnterminatedInterpolation(t *testing.T) {
+	_, errs := scan(`"${x`)
+	if len(errs) != 1 {
+		t.Errorf("Expected one error, got: %v", errs)
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	cases := map[string]float64{
+		"42":          42,
+		"3.25":        3.25,
+		"0xFF":        255,
+		"0Xff":        255,
+		"0b1010":      10,
+		"1e-9":        1e-9,
+		"2.5E3":       2500,
+		"1e+2":        100,
+		"1_000_000":   1000000,
+		"0xdead_beef": 0xdeadbeef,
+		"0b1111_0000": 240,
+		"1_0.0_1":     10.01,
+	}
+	for src, expected := range cases {
+		tokens, errs := scan(src)
+		if len(errs) != 0 {
+			t.Errorf("%s: expected no errors, got: %v", src, errs)
+			continue
+		}
+		if tokens[0].Type != token.Tnumber || tokens[0].Literal != expected {
+			t.Errorf("%s: expected %v, got: %v (%v)", src, expected, tokens[0], tokens[0].Literal)
+		}
+	}
+}
+
+func TestMalformedNumbers(t *testing.T) {
+	for _, src := range []string{
+		"0x", "0xG", "0b102", "0b", "1e", "1e+", "1__0", "1_", "0x_1", "1_.5", "123abc", "1e999",
+	} {
+		tokens, errs := scan(src)
+		if len(errs) != 1 {
+			t.Errorf("%s: expected one error, got: %v", src, errs)
+		}
+		if len(tokens) != 2 || tokens[0].Type != token.Tnumber {
+			t.Errorf("%s: expected a single number token, got: %v", src, tokens)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	for src, expected := range map[string]float64{"42": 42, "0xFF": 255, "0b11": 3, "1_000": 1000, "2.5e3": 2500} {
+		if got, ok := ParseNumber(src); !ok || got != expected {
+			t.Errorf("%s: expected %v, got: %v (%v)", src, expected, got, ok)
+		}
+	}
+	for _, src := range []string{"", " 1", "1 // x", "-1", ".5", "1.", "inf", "NaN", "1__0", "0x", "1e999", "1 2"} {
+		if got, ok := ParseNumber(src); ok {
+			t.Errorf("%s: expected not a number, got: %v", src, got)
+		}
+	}
+}
+
+func TestParseNumberQuiet(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	for _, src := range []string{"42", "0x1_F", "abc", "1__0"} {
+		ParseNumber(src)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected ParseNumber not to log, got: %q", buf.String())
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	for _, src := range []string{"my_var2", "_private", "__", "x1_2", "café", "变量", "Ⅻ", "é"} {
+		tokens, errs := scan(src)
+		if len(errs) != 0 {
+			t.Errorf("%s: expected no errors, got: %v", src, errs)
+		}
+		if len(tokens) != 2 || tokens[0].Type != token.Tidentifier || tokens[0].Lexeme != src {
+			t.Errorf("%s: expected a single identifier, got: %v", src, tokens)
+		}
+	}
+}
+
+func TestIdentifierKeywords(t *testing.T) {
+	tokens, _ := scan("var var_1 = nil;")
+	expected := []token.TokenType{
+		token.Tvar, token.Tidentifier, token.Tequal, token.Tnil, token.Tsemicolon, token.Teof,
+	}
+	for idx, ty := range expected {
+		if tokens[idx].Type != ty {
+			t.Errorf("token %d: expected %v, got: %v", idx, ty, tokens[idx])
+		}
+	}
+}
+
+func TestBlockComments(t *testing.T) {
+	tokens, errs := scan("a /* one\n/* two */\n*/ b /**/ c /* ** / */")
+	if len(errs) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errs)
+	}
+	if len(tokens) != 4 {
+		t.Fatalf("Expected 3 identifiers, got: %v", tokens)
+	}
+	if tokens[1].Lexeme != "b" || tokens[1].Line != 3 {
+		t.Errorf("Expected b on line 3, got: %v on line %d", tokens[1], tokens[1].Line)
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	tokens, errs := scan("a\n/* /* */\n")
+	if len(errs) != 1 || errs[0].line != 2 {
+		t.Errorf("Expected one error on line 2, got: %v", errs)
+	}
+	if last := tokens[len(tokens)-1]; last.Line != 3 {
+		t.Errorf("Expected EOF on line 3, got: %d", last.Line)
+	}
+}
+
+func TestOperators(t *testing.T) {
+	tokens, _ := scan("% ** ~/ ~ & | ^ << >> <= >= * / < >")
+	expected := []token.TokenType{
+		token.Tpercent, token.TstarStar, token.TtildeSlash, token.Ttilde, token.Tamp, token.Tpipe,
+		token.Tcaret, token.TlessLess, token.TgreaterGreater, token.TlessEqual, token.TgreaterEqual,
+		token.Tstar, token.Tslash, token.Tless, token.Tgreater, token.Teof,
+	}
+	for idx, ty := range expected {
+		if tokens[idx].Type != ty {
+			t.Errorf("token %d: expected %v, got: %v", idx, ty, tokens[idx])
+		}
+	}
+}
+
+func TestAssignmentOperators(t *testing.T) {
+	tokens, _ := scan("+= -= *= /= ++ -- + - // comment")
+	expected := []token.TokenType{
+		token.TplusEqual, token.TminusEqual, token.TstarEqual, token.TslashEqual,
+		token.TplusPlus, token.TminusMinus, token.Tplus, token.Tminus, token.Teof,
+	}
+	for idx, ty := range expected {
+		if tokens[idx].Type != ty {
+			t.Errorf("token %d: expected %v, got: %v", idx, ty, tokens[idx])
+		}
+	}
+}
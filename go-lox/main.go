@@ -27,8 +27,16 @@ func logTokens(tokens []token.Token) {
 func run(src string, interp *interpreter.Interpreter) error {
 	log.Printf("src: '%s'\n", src)
 	lexer := lexer.NewLexer(src)
+	lexerErrOccured := false
+	lexer.ErrorHandler = func(line int, msg string) {
+		lexerErrOccured = true
+		fmt.Printf("[line %d] lexer error: %s\n", line, msg)
+	}
 	tokens := lexer.ScanTokens()
 	logTokens(tokens)
+	if lexerErrOccured {
+		return nil
+	}
 
 	parser := parser.NewParser(tokens)
 	// parserErrOccured := false
@@ -41,6 +49,13 @@ func run(src string, interp *interpreter.Interpreter) error {
 		for _, stmt := range expr {
 			log.Printf("AST: %s", ast.NewAstPrinter().PrintStatement(stmt))
 		}
+		resolver := interpreter.NewResolver()
+		resolver.ErrorHandler = func(tok token.Token, msg string) {
+			fmt.Printf("[line %d] resolver error: %s\n", tok.Line, msg)
+		}
+		if resolver.Resolve(expr) {
+			return nil
+		}
 		interp.Interpret(expr)
 		// log.Printf("Evaluated value: %v\n", val)
 	}
@@ -48,8 +63,10 @@ func run(src string, interp *interpreter.Interpreter) error {
 	return nil
 }
 
-func runFile(path string) {
+func runFile(path string, args []string) {
 	interp := interpreter.NewInterpreter()
+	interp.SetScriptPath(path)
+	interp.SetArgs(args)
 	src, err := ioutil.ReadFile(path)
 	if err != nil {
 		panic(err)
@@ -78,12 +95,9 @@ func setupLogger() {
 func main() {
 	setupLogger()
 	argsLen := len(os.Args)
-	if argsLen > 2 {
-		fmt.Printf("Usage: %s [script]\n", os.Args[0])
-		return
-	} else if argsLen == 2 {
-		//
-		runFile(os.Args[1])
+	if argsLen >= 2 {
+		// the arguments after the script are passed to it
+		runFile(os.Args[1], os.Args[2:])
 	} else {
 		//
 		log.Println("repl")
@@ -51,12 +51,19 @@ Grammar
 program     → declaration* EOF ;
 
 declaration → varDecl
+			| constDecl
 			| funcDecl
+			| importDecl
+			| "export" ( varDecl | constDecl | funcDecl )
 			| statement ;
 
+importDecl -> "import" STRING "as" IDENTIFIER ";" ;
+
 funcDecl -> "fun" function;
 function -> IDENTIFIER "(" parameters? ")" block ;
-parameters -> IDENTIFIER ( "," IDENTIFIER )* ;
+parameters -> ( parameter ( "," parameter )* ( "," "..." IDENTIFIER )? )
+			| "..." IDENTIFIER ;
+parameter -> IDENTIFIER ( "=" expression )? ;
 
 statement   → exprStmt
 			| ifStmt
@@ -64,10 +71,27 @@ statement   → exprStmt
 			| whileStmt
 			| returnStmt
 			| forStmt
+			| throwStmt
+			| tryStmt
+			| matchStmt
 			| block ;
 
 returnStmt -> RETURN expression? ";" ;
 
+throwStmt -> "throw" expression ";" ;
+
+matchStmt -> "match" "(" expression ")" "{" matchCase* ( "default" "=>" statement )? "}" ;
+matchCase -> "case" pattern ( "," pattern )* "=>" statement ;
+pattern -> NUMBER | "-" NUMBER | STRING | "true" | "false" | "nil"
+			| IDENTIFIER
+			| "[" ( pattern ( "," pattern )* )? ( ","? "..." IDENTIFIER )? "]"
+			| "{" ( mapPattern ( "," mapPattern )* )? "}" ;
+mapPattern -> IDENTIFIER | ( IDENTIFIER | STRING | NUMBER ) ":" pattern ;
+
+tryStmt -> "try" block
+			( "catch" "(" IDENTIFIER ")" block )?
+			( "finally" block )? ;
+
 forStmt -> "for" "(" (varDecl | exprStmt | ";")
 					expression? ;
 					expression? ")" statement ;
@@ -78,40 +102,109 @@ whileStmt -> "while" "(" expression ")" statement;
 
 block  -> "{" declaration* "}";
 
-varDecl → "var" IDENTIFIER ( "=" expression )? ";" ;
+varDecl → "var" IDENTIFIER ( "=" expression )? ";"
+		| "var" destructure ;
+constDecl → "const" ( IDENTIFIER "=" expression ";" | destructure ) ;
+destructure → ( listPattern | mapPattern ) "=" expression ";" ;
 
 exprStmt  → expression ";" ;
 printStmt → "print" expression ";" ;
 
 expression     → assignment ;
-assignment -> IDENTIFIER "=" assignment
-			| logic_or;
+assignment -> ( IDENTIFIER | call "[" expression "]" | call "." IDENTIFIER )
+				( "=" | "+=" | "-=" | "*=" | "/=" ) assignment
+			| conditional;
+conditional -> coalesce ( "?" expression ":" conditional )? ;
+coalesce -> logic_or ("??" logic_or)* ;
 logic_or -> logic_and ("or" logic_and)* ;
 logic_and -> equality ("and" equality)* ;
 equality       → comparison ( ( "!=" | "==" ) comparison )* ;
-comparison     → addition ( ( ">" | ">=" | "<" | "<=" ) addition )* ;
+comparison     → bit_or ( ( ">" | ">=" | "<" | "<=" ) bit_or )* ;
+bit_or         → bit_xor ( "|" bit_xor )* ;
+bit_xor        → bit_and ( "^" bit_and )* ;
+bit_and        → shift ( "&" shift )* ;
+shift          → addition ( ( "<<" | ">>" ) addition )* ;
 addition       → multiplication ( ( "-" | "+" ) multiplication )* ;
-multiplication → unary ( ( "/" | "*" ) unary )* ;
-unary          → ( "!" | "-" ) unary
-			   | call ;
-
-call -> primary ( "(" arguments? ")" )* ;
-arguments -> expression ("," expression)* ;
+multiplication → unary ( ( "/" | "*" | "%" | "~/" ) unary )* ;
+unary          → ( "!" | "-" | "~" | "++" | "--" ) unary
+			   | exponent ;
+exponent       → postfix ( "**" unary )? ;
+postfix        → call ( "++" | "--" )? ;
+
+call -> primary ( "(" arguments? ")" | "." IDENTIFIER | "[" expression "]" )* ;
+arguments -> positional ( "," named )*
+			| named ( "," named )* ;
+positional -> expression ( "," expression )* ;
+named -> IDENTIFIER ":" expression ;
 primary        → NUMBER | STRING | "false" | "true" | "nil"
 			   | "(" expression ")"
+			   | interpolation
+			   | "[" ( expression ( "," expression )* ","? )? "]"
+			   | "{" ( mapEntry ( "," mapEntry )* ","? )? "}"
 			   | IDENTIFIER;
+mapEntry -> ( IDENTIFIER | coalesce ) ":" expression ;
+interpolation -> ( INTERPOLATION expression )+ STRING ;
 */
 
 func (p *Parser) declaration() (ast.Stmt, error) {
 	if p.match(token.Tvar) {
 		return p.varDecl()
 	}
+	if p.match(token.Tconst) {
+		return p.constDecl()
+	}
 	if p.match(token.Tfun) {
 		return p.funcDecl()
 	}
+	if p.match(token.Timport) {
+		return p.importDecl()
+	}
+	if p.match(token.Texport) {
+		return p.exportDecl()
+	}
 	return p.statement()
 }
 
+func (p *Parser) importDecl() (ast.Stmt, error) {
+	keyword := p.previous()
+	path := p.peek()
+	err := p.consume(token.Tstring, "Expected module path after import")
+	if err != nil {
+		return nil, err
+	}
+	// `as` is not a keyword, so that it can still be used as a name
+	if as := p.peek(); as.Type != token.Tidentifier || as.Lexeme != "as" {
+		return nil, p.err(as, "Expected 'as' after module path")
+	}
+	p.advance()
+	name := p.peek()
+	err = p.consume(token.Tidentifier, "Expected module name after 'as'")
+	if err != nil {
+		return nil, err
+	}
+	return ast.Simport{Keyword: keyword, Path: path.Literal.(string), Name: name},
+		p.consume(token.Tsemicolon, "Expected a semicolon")
+}
+
+func (p *Parser) exportDecl() (ast.Stmt, error) {
+	keyword := p.previous()
+	var decl ast.Stmt
+	var err error
+	if p.match(token.Tvar) {
+		decl, err = p.varDecl()
+	} else if p.match(token.Tconst) {
+		decl, err = p.constDecl()
+	} else if p.match(token.Tfun) {
+		decl, err = p.funcDecl()
+	} else {
+		return nil, p.err(p.peek(), "Expected declaration after export")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ast.Sexport{Keyword: keyword, Declaration: decl}, nil
+}
+
 func (p *Parser) funcDecl() (ast.Stmt, error) {
 	if name := p.peek(); p.match(token.Tidentifier) {
 		err := p.consume(token.TleftParen, "expected ( after function name")
@@ -119,13 +212,36 @@ func (p *Parser) funcDecl() (ast.Stmt, error) {
 			return nil, err
 		}
 		params := make([]token.Token, 0)
+		defaults := make([]ast.Expr, 0)
+		var rest *token.Token
 		if !p.match(token.TrightParen) {
 			for {
-				if p.check(token.Tidentifier) {
-					params = append(params, p.peek())
-					p.advance()
+				if p.match(token.Tellipsis) {
+					name := p.peek()
+					err = p.consume(token.Tidentifier, "expected identifier after ...")
+					if err != nil {
+						return nil, err
+					}
+					rest = &name
+					// the rest parameter has to be the last one
+					break
+				}
+				param := p.peek()
+				err = p.consume(token.Tidentifier, "expected identifier")
+				if err != nil {
+					return nil, err
+				}
+				params = append(params, param)
+				if p.match(token.Tequal) {
+					def, err := p.expression()
+					if err != nil {
+						return nil, err
+					}
+					defaults = append(defaults, def)
+				} else if len(defaults) != 0 && defaults[len(defaults)-1] != nil {
+					return nil, p.err(param, "parameter without a default value after one with a default value")
 				} else {
-					return nil, p.err(p.peek(), "expected identifier")
+					defaults = append(defaults, nil)
 				}
 				if !p.match(token.Tcomma) {
 					break
@@ -141,12 +257,21 @@ func (p *Parser) funcDecl() (ast.Stmt, error) {
 		if err != nil {
 			return nil, err
 		}
-		return ast.Sfunction{Name: name, Params: params, Body: body.(ast.Sblock).Stmts}, nil
+		return ast.Sfunction{
+			Name:     name,
+			Params:   params,
+			Defaults: defaults,
+			Rest:     rest,
+			Body:     body.(ast.Sblock).Stmts,
+		}, nil
 	}
 	return nil, p.err(p.peek(), "expected funciton indentifier")
 }
 
 func (p *Parser) varDecl() (ast.Stmt, error) {
+	if p.check(token.TleftBracket) || p.check(token.TleftBrace) {
+		return p.destructure(false)
+	}
 	iden := p.peek()
 
 	// consume current token, and confirm it is an identifier
@@ -166,6 +291,47 @@ func (p *Parser) varDecl() (ast.Stmt, error) {
 
 }
 
+func (p *Parser) constDecl() (ast.Stmt, error) {
+	if p.check(token.TleftBracket) || p.check(token.TleftBrace) {
+		return p.destructure(true)
+	}
+	iden := p.peek()
+
+	err := p.consume(token.Tidentifier, "Expected identifier")
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(token.Tequal, "Expected = after constant name")
+	if err != nil {
+		return nil, err
+	}
+	initializer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return ast.Svar{Name: iden, Expression: initializer, Const: true}, p.consume(token.Tsemicolon, "Expected a semicolon")
+}
+
+func (p *Parser) destructure(constant bool) (ast.Stmt, error) {
+	keyword := p.previous()
+	// only called when the next token is [ or {, so this is a list or
+	// map pattern
+	pattern, err := p.pattern()
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(token.Tequal, "Expected = after destructuring pattern")
+	if err != nil {
+		return nil, err
+	}
+	initializer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return ast.Sdestructure{Keyword: keyword, Pattern: pattern, Expression: initializer, Const: constant},
+		p.consume(token.Tsemicolon, "Expected a semicolon")
+}
+
 func (p *Parser) statement() (ast.Stmt, error) {
 	if p.match(token.Tprint) {
 		return p.printStatement()
@@ -185,9 +351,233 @@ func (p *Parser) statement() (ast.Stmt, error) {
 	if p.match(token.Treturn) {
 		return p.returnStmt()
 	}
+	if p.match(token.Tthrow) {
+		return p.throwStmt()
+	}
+	if p.match(token.Ttry) {
+		return p.tryStmt()
+	}
+	if p.match(token.Tmatch) {
+		return p.matchStmt()
+	}
 	return p.exprStatement()
 }
 
+func (p *Parser) matchStmt() (ast.Stmt, error) {
+	keyword := p.previous()
+	err := p.consume(token.TleftParen, "Expected ( after match")
+	if err != nil {
+		return nil, err
+	}
+	subject, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(token.TrightParen, "Expected ) after match subject")
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(token.TleftBrace, "Expected { before match cases")
+	if err != nil {
+		return nil, err
+	}
+
+	matchStmt := ast.Smatch{Keyword: keyword, Subject: subject, Cases: make([]ast.MatchCase, 0)}
+	for p.match(token.Tcase) {
+		patterns := make([]ast.Pattern, 0)
+		for {
+			pattern, err := p.pattern()
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, pattern)
+			if !p.match(token.Tcomma) {
+				break
+			}
+		}
+		err = p.consume(token.TequalGreater, "Expected => after case patterns")
+		if err != nil {
+			return nil, err
+		}
+		body, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		matchStmt.Cases = append(matchStmt.Cases, ast.MatchCase{Patterns: patterns, Body: body})
+	}
+
+	if p.match(token.Tdefault) {
+		err = p.consume(token.TequalGreater, "Expected => after default")
+		if err != nil {
+			return nil, err
+		}
+		matchStmt.Default, err = p.statement()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matchStmt, p.consume(token.TrightBrace, "Expected } after match cases")
+}
+
+func (p *Parser) pattern() (ast.Pattern, error) {
+	if p.match(token.Tnumber, token.Tstring) {
+		return ast.Pliteral{Value: p.previous().Literal}, nil
+	}
+	if p.match(token.Tminus) {
+		num := p.peek()
+		err := p.consume(token.Tnumber, "Expected number after - in pattern")
+		if err != nil {
+			return nil, err
+		}
+		return ast.Pliteral{Value: -num.Literal.(float64)}, nil
+	}
+	if p.match(token.Ttrue) {
+		return ast.Pliteral{Value: true}, nil
+	}
+	if p.match(token.Tfalse) {
+		return ast.Pliteral{Value: false}, nil
+	}
+	if p.match(token.Tnil) {
+		return ast.Pliteral{Value: nil}, nil
+	}
+	if p.match(token.Tidentifier) {
+		if p.previous().Lexeme == "_" {
+			return ast.Pwildcard{}, nil
+		}
+		return ast.Pbinding{Name: p.previous()}, nil
+	}
+	if p.match(token.TleftBracket) {
+		return p.listPattern()
+	}
+	if p.match(token.TleftBrace) {
+		return p.mapPattern()
+	}
+	return nil, p.err(p.peek(), "Expected pattern")
+}
+
+func (p *Parser) listPattern() (ast.Pattern, error) {
+	list := ast.Plist{Bracket: p.previous(), Elements: make([]ast.Pattern, 0)}
+	for !p.check(token.TrightBracket) {
+		if p.match(token.Tellipsis) {
+			name := p.peek()
+			err := p.consume(token.Tidentifier, "Expected identifier after ...")
+			if err != nil {
+				return nil, err
+			}
+			list.Rest = &name
+			// the rest pattern has to be the last one
+			break
+		}
+		element, err := p.pattern()
+		if err != nil {
+			return nil, err
+		}
+		list.Elements = append(list.Elements, element)
+		if !p.match(token.Tcomma) {
+			break
+		}
+	}
+	return list, p.consume(token.TrightBracket, "Expected ] after list pattern")
+}
+
+func (p *Parser) mapPattern() (ast.Pattern, error) {
+	m := ast.Pmap{Brace: p.previous(), Keys: make([]interface{}, 0), Values: make([]ast.Pattern, 0)}
+	for !p.check(token.TrightBrace) {
+		key := p.peek()
+		if !p.match(token.Tidentifier, token.Tstring, token.Tnumber) {
+			return nil, p.err(key, "Expected key in map pattern")
+		}
+		if key.Type == token.Tidentifier && !p.check(token.Tcolon) {
+			// `{name}` is short for `{name: name}`
+			m.Keys = append(m.Keys, key.Lexeme)
+			m.Values = append(m.Values, ast.Pbinding{Name: key})
+		} else {
+			err := p.consume(token.Tcolon, "Expected : after key in map pattern")
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.pattern()
+			if err != nil {
+				return nil, err
+			}
+			if key.Type == token.Tidentifier {
+				m.Keys = append(m.Keys, key.Lexeme)
+			} else {
+				m.Keys = append(m.Keys, key.Literal)
+			}
+			m.Values = append(m.Values, value)
+		}
+		if !p.match(token.Tcomma) {
+			break
+		}
+	}
+	return m, p.consume(token.TrightBrace, "Expected } after map pattern")
+}
+
+func (p *Parser) throwStmt() (ast.Stmt, error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return ast.Sthrow{Keyword: keyword, Value: value}, p.consume(token.Tsemicolon, "Expected semicolon after throw")
+}
+
+func (p *Parser) tryStmt() (ast.Stmt, error) {
+	keyword := p.previous()
+	body, err := p.blockBody("Expected { after try")
+	if err != nil {
+		return nil, err
+	}
+	tryStmt := ast.Stry{Body: body}
+
+	if p.match(token.Tcatch) {
+		err = p.consume(token.TleftParen, "Expected ( after catch")
+		if err != nil {
+			return nil, err
+		}
+		name := p.peek()
+		err = p.consume(token.Tidentifier, "Expected identifier in catch")
+		if err != nil {
+			return nil, err
+		}
+		err = p.consume(token.TrightParen, "Expected ) after catch identifier")
+		if err != nil {
+			return nil, err
+		}
+		tryStmt.CatchName = &name
+		tryStmt.CatchBody, err = p.blockBody("Expected { after catch")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.match(token.Tfinally) {
+		tryStmt.FinallyBody, err = p.blockBody("Expected { after finally")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tryStmt.CatchName == nil && tryStmt.FinallyBody == nil {
+		return nil, p.err(keyword, "Expected catch or finally after try")
+	}
+	return tryStmt, nil
+}
+
+// blockBody parses a `{ ... }` block that is mandatory, like in try/catch
+func (p *Parser) blockBody(message string) ([]ast.Stmt, error) {
+	err := p.consume(token.TleftBrace, message)
+	if err != nil {
+		return nil, err
+	}
+	block, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return block.(ast.Sblock).Stmts, nil
+}
+
 func (p *Parser) returnStmt() (ast.Stmt, error) {
 	keyword := p.previous()
 	value, err := p.expression()
@@ -241,9 +631,16 @@ func (p *Parser) forStmt() (ast.Stmt, error) {
 		return nil, err
 	}
 
+	if cond == nil {
+		cond = ast.Literal{Value: true}
+	}
+	loopBody := []ast.Stmt{body}
+	if increment != nil {
+		loopBody = append(loopBody, ast.Sexpression{Expression: increment})
+	}
 	whileStmt := ast.Swhile{
 		Condition: cond,
-		Body:      ast.Sblock{Stmts: []ast.Stmt{body, ast.Sexpression{Expression: increment}}},
+		Body:      ast.Sblock{Stmts: loopBody},
 	}
 
 	if initializer != nil {
@@ -330,7 +727,7 @@ func (p *Parser) expression() (ast.Expr, error) {
 }
 
 func (p *Parser) assignment() (ast.Expr, error) {
-	expr, err := p.logic_or()
+	expr, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
@@ -343,8 +740,84 @@ func (p *Parser) assignment() (ast.Expr, error) {
 			}
 			return ast.Eassign{Name: w.Name, Value: rval}, nil
 		}
+		if w, ok := expr.(ast.Eindex); ok {
+			rval, err := p.assignment()
+			if err != nil {
+				return nil, err
+			}
+			return ast.EindexSet{Object: w.Object, Bracket: w.Bracket, Index: w.Index, Value: rval}, nil
+		}
+		if w, ok := expr.(ast.Eget); ok {
+			rval, err := p.assignment()
+			if err != nil {
+				return nil, err
+			}
+			return ast.Eset{Object: w.Object, Name: w.Name, Value: rval}, nil
+		}
 		return nil, p.err(p.previous(), "lvalue of assignment is wrong")
 	}
+	if p.match(token.TplusEqual, token.TminusEqual, token.TstarEqual, token.TslashEqual) {
+		op := p.previous()
+		if !isAssignable(expr) {
+			return nil, p.err(op, "lvalue of assignment is wrong")
+		}
+		rval, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		return ast.Ecompound{Target: expr, Op: op, Value: rval}, nil
+	}
+	return expr, nil
+}
+
+// isAssignable reports whether expr can be the target of a compound
+// assignment or an increment
+func isAssignable(expr ast.Expr) bool {
+	switch expr.(type) {
+	case ast.Evariable, ast.Eindex, ast.Eget:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) conditional() (ast.Expr, error) {
+	expr, err := p.coalesce()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.Tquestion) {
+		thenBranch, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		err = p.consume(token.Tcolon, "Expected : in conditional expression")
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		expr = ast.Econditional{Condition: expr, ThenBranch: thenBranch, ElseBranch: elseBranch}
+	}
+	return expr, nil
+}
+
+func (p *Parser) coalesce() (ast.Expr, error) {
+	expr, err := p.logic_or()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.TquestionQuestion) {
+		op := p.previous()
+		right, err := p.logic_or()
+		if err != nil {
+			return nil, err
+		}
+		expr = ast.Elogical{Left: expr, Op: op, Right: right}
+	}
 	return expr, nil
 }
 
@@ -401,12 +874,80 @@ func (p *Parser) equality() (ast.Expr, error) {
 }
 
 func (p *Parser) comparison() (ast.Expr, error) {
-	expr, err := p.addition()
+	expr, err := p.bit_or()
 	if err != nil {
 		return nil, err
 	}
 
 	for p.match(token.Tless, token.TlessEqual, token.Tgreater, token.TgreaterEqual) {
+		op := p.previous()
+		right, err := p.bit_or()
+		if err != nil {
+			return nil, err
+		}
+		expr = ast.Binary{Left: expr, Op: op, Right: right}
+	}
+	return expr, nil
+}
+
+func (p *Parser) bit_or() (ast.Expr, error) {
+	expr, err := p.bit_xor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.Tpipe) {
+		op := p.previous()
+		right, err := p.bit_xor()
+		if err != nil {
+			return nil, err
+		}
+		expr = ast.Binary{Left: expr, Op: op, Right: right}
+	}
+	return expr, nil
+}
+
+func (p *Parser) bit_xor() (ast.Expr, error) {
+	expr, err := p.bit_and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.Tcaret) {
+		op := p.previous()
+		right, err := p.bit_and()
+		if err != nil {
+			return nil, err
+		}
+		expr = ast.Binary{Left: expr, Op: op, Right: right}
+	}
+	return expr, nil
+}
+
+func (p *Parser) bit_and() (ast.Expr, error) {
+	expr, err := p.shift()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.Tamp) {
+		op := p.previous()
+		right, err := p.shift()
+		if err != nil {
+			return nil, err
+		}
+		expr = ast.Binary{Left: expr, Op: op, Right: right}
+	}
+	return expr, nil
+}
+
+func (p *Parser) shift() (ast.Expr, error) {
+	expr, err := p.addition()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.TlessLess, token.TgreaterGreater) {
 		op := p.previous()
 		right, err := p.addition()
 		if err != nil {
@@ -440,7 +981,7 @@ func (p *Parser) multiplication() (ast.Expr, error) {
 		return nil, err
 	}
 
-	for p.match(token.Tstar, token.Tslash) {
+	for p.match(token.Tstar, token.Tslash, token.Tpercent, token.TtildeSlash) {
 		op := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -452,7 +993,7 @@ func (p *Parser) multiplication() (ast.Expr, error) {
 }
 
 func (p *Parser) unary() (ast.Expr, error) {
-	if p.match(token.Tbang, token.Tminus) {
+	if p.match(token.Tbang, token.Tminus, token.Ttilde) {
 		op := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -460,7 +1001,52 @@ func (p *Parser) unary() (ast.Expr, error) {
 		}
 		return ast.Unary{Op: op, Right: right}, nil
 	}
-	return p.call()
+	if p.match(token.TplusPlus, token.TminusMinus) {
+		op := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !isAssignable(target) {
+			return nil, p.err(op, "invalid target for "+op.Lexeme)
+		}
+		return ast.Eincrement{Target: target, Op: op, Prefix: true}, nil
+	}
+	return p.exponent()
+}
+
+func (p *Parser) exponent() (ast.Expr, error) {
+	expr, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.TstarStar) {
+		op := p.previous()
+		// right associative: 2 ** 3 ** 2 is 2 ** (3 ** 2)
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		expr = ast.Binary{Left: expr, Op: op, Right: right}
+	}
+	return expr, nil
+}
+
+func (p *Parser) postfix() (ast.Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.TplusPlus, token.TminusMinus) {
+		op := p.previous()
+		if !isAssignable(expr) {
+			return nil, p.err(op, "invalid target for "+op.Lexeme)
+		}
+		return ast.Eincrement{Target: expr, Op: op, Prefix: false}, nil
+	}
+	return expr, nil
 }
 
 func (p *Parser) call() (ast.Expr, error) {
@@ -474,6 +1060,24 @@ func (p *Parser) call() (ast.Expr, error) {
 			if err != nil {
 				return nil, err
 			}
+		} else if p.match(token.Tdot) {
+			name := p.peek()
+			err = p.consume(token.Tidentifier, "Expected property name after '.'")
+			if err != nil {
+				return nil, err
+			}
+			expr = ast.Eget{Object: expr, Name: name}
+		} else if p.match(token.TleftBracket) {
+			bracket := p.previous()
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			err = p.consume(token.TrightBracket, "Expected ] after index")
+			if err != nil {
+				return nil, err
+			}
+			expr = ast.Eindex{Object: expr, Bracket: bracket, Index: index}
 		} else {
 			break
 		}
@@ -483,19 +1087,39 @@ func (p *Parser) call() (ast.Expr, error) {
 
 func (p *Parser) finishCall(expr ast.Expr) (ast.Expr, error) {
 	args := make([]ast.Expr, 0)
+	named := make([]ast.NamedArg, 0)
+	seen := make(map[string]bool)
 	if !p.check(token.TrightParen) {
 		for {
-			arg, err := p.expression()
-			if err != nil {
-				return nil, err
+			if p.check(token.Tidentifier) && p.checkNext(token.Tcolon) {
+				name := p.peek()
+				p.advance()
+				p.advance()
+				if seen[name.Lexeme] {
+					return nil, p.err(name, "duplicate named argument '"+name.Lexeme+"'")
+				}
+				seen[name.Lexeme] = true
+				arg, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				named = append(named, ast.NamedArg{Name: name, Value: arg})
+			} else {
+				if len(named) != 0 {
+					return nil, p.err(p.peek(), "positional argument after named arguments")
+				}
+				arg, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
 			}
-			args = append(args, arg)
 			if !p.match(token.Tcomma) {
 				break
 			}
 		}
 	}
-	return ast.Ecall{Callee: expr, Paren: p.peek(), Args: args},
+	return ast.Ecall{Callee: expr, Paren: p.peek(), Args: args, Named: named},
 		p.consume(token.TrightParen, "Expected ) after call")
 }
 
@@ -513,9 +1137,18 @@ func (p *Parser) primary() (ast.Expr, error) {
 	if p.match(token.Tnumber, token.Tstring) {
 		return ast.Literal{Value: p.previous().Literal}, nil
 	}
+	if p.match(token.Tinterpolation) {
+		return p.interpolation()
+	}
 	if p.match(token.Tidentifier) {
 		return ast.Evariable{p.previous()}, nil
 	}
+	if p.match(token.TleftBracket) {
+		return p.list()
+	}
+	if p.match(token.TleftBrace) {
+		return p.mapLiteral()
+	}
 
 	if p.match(token.TleftParen) {
 		expr, err := p.expression()
@@ -532,6 +1165,83 @@ func (p *Parser) primary() (ast.Expr, error) {
 	return nil, p.err(p.peek(), "Expected expression")
 }
 
+func (p *Parser) list() (ast.Expr, error) {
+	bracket := p.previous()
+	elements := make([]ast.Expr, 0)
+	for !p.check(token.TrightBracket) {
+		element, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+		if !p.match(token.Tcomma) {
+			break
+		}
+	}
+	return ast.Elist{Bracket: bracket, Elements: elements},
+		p.consume(token.TrightBracket, "Expected ] after list elements")
+}
+
+func (p *Parser) mapLiteral() (ast.Expr, error) {
+	brace := p.previous()
+	keys := make([]ast.Expr, 0)
+	values := make([]ast.Expr, 0)
+	for !p.check(token.TrightBrace) {
+		var key ast.Expr
+		if p.check(token.Tidentifier) && p.checkNext(token.Tcolon) {
+			// `{name: 1}` uses the string "name" as the key
+			key = ast.Literal{Value: p.peek().Lexeme}
+			p.advance()
+		} else {
+			// keys are parsed below conditional, whose `:` would be
+			// ambiguous with the one after the key. `{(a ? b : c): 1}`
+			// needs parentheses.
+			var err error
+			key, err = p.coalesce()
+			if err != nil {
+				return nil, err
+			}
+		}
+		err := p.consume(token.Tcolon, "Expected : after map key")
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+		if !p.match(token.Tcomma) {
+			break
+		}
+	}
+	return ast.Emap{Brace: brace, Keys: keys, Values: values},
+		p.consume(token.TrightBrace, "Expected } after map entries")
+}
+
+func (p *Parser) interpolation() (ast.Expr, error) {
+	parts := make([]ast.Expr, 0)
+	for {
+		segment := p.previous()
+		if text := segment.Literal.(string); text != "" {
+			parts = append(parts, ast.Literal{Value: text})
+		}
+		if segment.Type == token.Tstring {
+			break
+		}
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, expr)
+		if !p.match(token.Tinterpolation, token.Tstring) {
+			return nil, p.err(p.peek(), "Expected '}' after interpolated expression")
+		}
+	}
+	return ast.Einterpolation{Parts: parts}, nil
+}
+
 func (p *Parser) consume(token token.TokenType, message string) error {
 	if p.check(token) {
 		p.advance()
@@ -552,6 +1262,14 @@ func (p *Parser) check(tt token.TokenType) bool {
 	return tt == p.peek().Type
 }
 
+// checkNext checks the token after the current one
+func (p *Parser) checkNext(tt token.TokenType) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return tt == p.tokens[p.current+1].Type
+}
+
 func (p *Parser) match(tts ...token.TokenType) bool {
 	for _, tokenType := range tts {
 		if p.check(tokenType) {
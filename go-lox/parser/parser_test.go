@@ -43,3 +43,266 @@ func TestParserErr(t *testing.T) {
 	}
 	_ = ast.NewAstPrinter().PrintStatement(stmts[0])
 }
+
+func TestParserInterpolation(t *testing.T) {
+	src := `"a ${1 + 2} b ${c}";`
+	stmts, hadError := parse(src)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	got := ast.NewAstPrinter().PrintStatement(stmts[0])
+	expected := "(interpolate a  (+ 1 2)  b  (variable c))"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %s", expected, got)
+	}
+}
+
+func TestParserTry(t *testing.T) {
+	src := `try { throw e.message; } catch (e) { print e; } finally { print 1; }`
+	stmts, hadError := parse(src)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	got := ast.NewAstPrinter().PrintStatement(stmts[0])
+	expected := "(try (body (throw (get message (variable e)))) (catch e (print (variable e))) (finally (print 1)))"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %s", expected, got)
+	}
+
+	_, hadError = parse(`try { }`)
+	if !hadError {
+		t.Errorf("Expected an error for try without catch or finally")
+	}
+}
+
+func TestParserOperatorPrecedence(t *testing.T) {
+	cases := map[string]string{
+		"a | b ^ c & d << e + f;": "(| (variable a) (^ (variable b) (& (variable c) (<< (variable d) (+ (variable e) (variable f))))))",
+		"a ** b ** c;":            "(** (variable a) (** (variable b) (variable c)))",
+		"-a ** b;":                "(- (** (variable a) (variable b)))",
+		"a % b ~/ c;":             "(~/ (% (variable a) (variable b)) (variable c))",
+		"a < b | c;":              "(< (variable a) (| (variable b) (variable c)))",
+	}
+	for src, expected := range cases {
+		stmts, hadError := parse(src)
+		if hadError {
+			t.Errorf("%s: unexpected parser error", src)
+			continue
+		}
+		got := ast.NewAstPrinter().PrintStatement(stmts[0])
+		if got != expected {
+			t.Errorf("Expected: %s, got: %s", expected, got)
+		}
+	}
+}
+
+func TestParserConditional(t *testing.T) {
+	cases := map[string]string{
+		"a ? b : c ? d : e;": "(?: (variable a) (variable b) (?: (variable c) (variable d) (variable e)))",
+		"a ?? b ? c : d;":    "(?: (?? (variable a) (variable b)) (variable c) (variable d))",
+		"a ?? b or c;":       "(?? (variable a) (or (variable b) (variable c)))",
+	}
+	for src, expected := range cases {
+		stmts, hadError := parse(src)
+		if hadError {
+			t.Errorf("%s: unexpected parser error", src)
+			continue
+		}
+		got := ast.NewAstPrinter().PrintStatement(stmts[0])
+		if got != expected {
+			t.Errorf("Expected: %s, got: %s", expected, got)
+		}
+	}
+}
+
+func TestParserCompoundAssignment(t *testing.T) {
+	cases := map[string]string{
+		"a += b -= 1;": "(+= (variable a) (-= (variable b) 1))",
+		"-a++;":        "(- (post++ (variable a)))",
+		"--a;":         "(pre-- (variable a))",
+		"a = b++;":     "(assign a (post++ (variable b)))",
+	}
+	for src, expected := range cases {
+		stmts, hadError := parse(src)
+		if hadError {
+			t.Errorf("%s: unexpected parser error", src)
+			continue
+		}
+		got := ast.NewAstPrinter().PrintStatement(stmts[0])
+		if got != expected {
+			t.Errorf("Expected: %s, got: %s", expected, got)
+		}
+	}
+
+	for _, src := range []string{"1 += 2;", "(a)++;", "++1;", "f() *= 2;"} {
+		if _, hadError := parse(src); !hadError {
+			t.Errorf("%s: expected a parser error", src)
+		}
+	}
+}
+
+func TestParserParameters(t *testing.T) {
+	stmts, hadError := parse(`fun f(a, b = 1, ...rest) {}`)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	f := stmts[0].(ast.Sfunction)
+	if len(f.Params) != 2 || f.Defaults[0] != nil || f.Defaults[1] == nil || f.Rest == nil || f.Rest.Lexeme != "rest" {
+		t.Errorf("Unexpected function declaration: %+v", f)
+	}
+
+	for _, src := range []string{
+		`fun f(a = 1, b) {}`,
+		`fun f(...rest, a) {}`,
+		`fun f(...) {}`,
+	} {
+		if _, hadError := parse(src); !hadError {
+			t.Errorf("%s: expected a parser error", src)
+		}
+	}
+}
+
+func TestParserList(t *testing.T) {
+	stmts, hadError := parse(`xs[0] = [1, xs[1][2], []];`)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	got := ast.NewAstPrinter().PrintStatement(stmts[0])
+	expected := "(index-set (variable xs) 0 (list 1 (index (index (variable xs) 1) 2) (list)))"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %s", expected, got)
+	}
+}
+
+func TestParserPropertySet(t *testing.T) {
+	stmts, hadError := parse(`a.b.c = x.y += 1;`)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	got := ast.NewAstPrinter().PrintStatement(stmts[0])
+	expected := "(set c (get b (variable a)) (+= (get y (variable x)) 1))"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %s", expected, got)
+	}
+}
+
+func TestParserNamedArguments(t *testing.T) {
+	stmts, hadError := parse(`f(1, b: 2, c: x ? y : z);`)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	got := ast.NewAstPrinter().PrintStatement(stmts[0])
+	expected := "(call (variable f) 1 b: 2 c: (?: (variable x) (variable y) (variable z)))"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %s", expected, got)
+	}
+
+	for _, src := range []string{`f(a: 1, a: 2);`, `f(a: 1, 2);`} {
+		if _, hadError := parse(src); !hadError {
+			t.Errorf("%s: expected a parser error", src)
+		}
+	}
+}
+
+func TestParserMatch(t *testing.T) {
+	src := `match (x) {
+		case 1, -2, "s", nil => print 1;
+		case [a, _, ...rest], {k, "s": [b], 3: c} => print a;
+		default => {}
+	}`
+	stmts, hadError := parse(src)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	got := ast.NewAstPrinter().PrintStatement(stmts[0])
+	expected := "(match (variable x) (case 1, -2, s, nil (print 1)) " +
+		"(case [a, _, ...rest], {k: k, s: [b], 3: c} (print (variable a))) (default (block\n )))"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %s", expected, got)
+	}
+
+	for _, src := range []string{
+		`match (x) { case => print 1; }`,
+		`match (x) { case 1 print 1; }`,
+		`match (x) { case [...a, b] => print 1; }`,
+		`match (x) { default => print 1; case 1 => print 2; }`,
+	} {
+		if _, hadError := parse(src); !hadError {
+			t.Errorf("%s: expected a parser error", src)
+		}
+	}
+}
+
+func TestParserMap(t *testing.T) {
+	stmts, hadError := parse(`var m = {a: 1, "b": 2, c + 1: {}};`)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	got := ast.NewAstPrinter().PrintStatement(stmts[0])
+	expected := "(var m (map a 1 b 2 (+ (variable c) 1) (map)))"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %s", expected, got)
+	}
+
+	stmts, hadError = parse(`var m = {(a ? b : c): d ? e : f, a ?? b: 1};`)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	got = ast.NewAstPrinter().PrintStatement(stmts[0])
+	expected = "(var m (map (group (?: (variable a) (variable b) (variable c))) (?: (variable d) (variable e) (variable f)) (?? (variable a) (variable b)) 1))"
+	if got != expected {
+		t.Errorf("Expected: %s, got: %s", expected, got)
+	}
+
+	for _, src := range []string{`var m = {a ? b : c: 1};`, `var m = {a = 1: 2};`} {
+		if _, hadError := parse(src); !hadError {
+			t.Errorf("%s: expected a parser error", src)
+		}
+	}
+}
+
+func TestParserDestructuring(t *testing.T) {
+	stmts, hadError := parse(`var [a, ...rest] = xs; const {name, age: [x]} = person;`)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	printer := ast.NewAstPrinter()
+	expected := []string{
+		"(var [a, ...rest] (variable xs))",
+		"(const {name: name, age: [x]} (variable person))",
+	}
+	for idx, stmt := range stmts {
+		if got := printer.PrintStatement(stmt); got != expected[idx] {
+			t.Errorf("Expected: %s, got: %s", expected[idx], got)
+		}
+	}
+
+	for _, src := range []string{`var [a, b];`, `var {a} = ;`, `var [a b] = xs;`} {
+		if _, hadError := parse(src); !hadError {
+			t.Errorf("%s: expected a parser error", src)
+		}
+	}
+}
+
+func TestParserImportExport(t *testing.T) {
+	stmts, hadError := parse(`import "lib/strings.lox" as strings; export const as = 1;`)
+	if hadError {
+		t.Fatalf("Unexpected parser error")
+	}
+	printer := ast.NewAstPrinter()
+	expected := []string{
+		`(import "lib/strings.lox" as strings)`,
+		"(export (const as 1))",
+	}
+	for idx, stmt := range stmts {
+		if got := printer.PrintStatement(stmt); got != expected[idx] {
+			t.Errorf("Expected: %s, got: %s", expected[idx], got)
+		}
+	}
+
+	for _, src := range []string{`import "a" b;`, `import a as b;`, `export print 1;`, `import "a" as;`} {
+		if _, hadError := parse(src); !hadError {
+			t.Errorf("%s: expected a parser error", src)
+		}
+	}
+}
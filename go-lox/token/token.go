@@ -10,6 +10,8 @@ const (
 	TrightParen
 	TleftBrace
 	TrightBrace
+	TleftBracket
+	TrightBracket
 	Tcomma
 	Tdot
 	Tminus
@@ -17,6 +19,13 @@ const (
 	Tsemicolon
 	Tslash
 	Tstar
+	Tpercent
+	Tamp
+	Tpipe
+	Tcaret
+	Ttilde
+	Tquestion
+	Tcolon
 
 	// one or two character tokens
 	Tbang
@@ -27,12 +36,28 @@ const (
 	TgreaterEqual
 	Tless
 	TlessEqual
+	TstarStar
+	TtildeSlash
+	TlessLess
+	TgreaterGreater
+	TquestionQuestion
+	TplusEqual
+	TminusEqual
+	TstarEqual
+	TslashEqual
+	TplusPlus
+	TminusMinus
+	Tellipsis
+	TequalGreater
 
 	// Literals
 	// TODO: Prefix with L?
 	Tidentifier
 	Tstring
 	Tnumber
+	// A string segment ending in "${", followed by the tokens of the
+	// interpolated expression
+	Tinterpolation
 
 	// Keywords
 	// TODO: Prefix with K?
@@ -52,6 +77,16 @@ const (
 	Ttrue
 	Tvar
 	Twhile
+	Tthrow
+	Ttry
+	Tcatch
+	Tfinally
+	Tconst
+	Tmatch
+	Tcase
+	Tdefault
+	Timport
+	Texport
 
 	Teof
 )
@@ -74,6 +109,8 @@ func tokenReverseLookup(ty TokenType) string {
 		"RightParen",
 		"LeftBrace",
 		"RightBrace",
+		"LeftBracket",
+		"RightBracket",
 		"Comma",
 		"Dot",
 		"Minus",
@@ -81,6 +118,13 @@ func tokenReverseLookup(ty TokenType) string {
 		"Semicolon",
 		"Slash",
 		"Star",
+		"Percent",
+		"Amp",
+		"Pipe",
+		"Caret",
+		"Tilde",
+		"Question",
+		"Colon",
 		"Bang",
 		"BangEq",
 		"Equal",
@@ -89,9 +133,23 @@ func tokenReverseLookup(ty TokenType) string {
 		"GreaterEqual",
 		"Less",
 		"LessEqual",
+		"StarStar",
+		"TildeSlash",
+		"LessLess",
+		"GreaterGreater",
+		"QuestionQuestion",
+		"PlusEqual",
+		"MinusEqual",
+		"StarEqual",
+		"SlashEqual",
+		"PlusPlus",
+		"MinusMinus",
+		"Ellipsis",
+		"EqualGreater",
 		"Identifier",
 		"String",
 		"Number",
+		"Interpolation",
 		"Keyword And",
 		"Keyword Class",
 		"Keyword Else",
@@ -108,6 +166,16 @@ func tokenReverseLookup(ty TokenType) string {
 		"Keyword true",
 		"Keyword var",
 		"Keyword while",
+		"Keyword throw",
+		"Keyword try",
+		"Keyword catch",
+		"Keyword finally",
+		"Keyword const",
+		"Keyword match",
+		"Keyword case",
+		"Keyword default",
+		"Keyword import",
+		"Keyword export",
 		"EOF",
 	}
 	return tokenNames[ty]
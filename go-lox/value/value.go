@@ -0,0 +1,198 @@
+package value
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// Kind is the type tag of a Value
+type Kind uint8
+
+const (
+	KindNil Kind = iota
+	KindBool
+	KindNumber
+	KindString
+	KindObject
+)
+
+// Object is a value with reference semantics, like a list or a function.
+// Objects are defined by the interpreter.
+type Object interface {
+	// TypeName is the name of the type used in runtime errors
+	TypeName() string
+}
+
+// Value is a lox value. Nils, bools, numbers and strings are stored inline,
+// so they don't have to be boxed into an interface{}.
+type Value struct {
+	kind Kind
+	// bools are stored as 0 or 1
+	num float64
+	str string
+	obj Object
+}
+
+// Nil is the lox nil. It's also the zero Value.
+var Nil = Value{}
+
+func NewBool(b bool) Value {
+	if b {
+		return Value{kind: KindBool, num: 1}
+	}
+	return Value{kind: KindBool}
+}
+
+func NewNumber(n float64) Value { return Value{kind: KindNumber, num: n} }
+
+func NewString(s string) Value { return Value{kind: KindString, str: s} }
+
+func NewObject(o Object) Value {
+	if o == nil {
+		return Nil
+	}
+	return Value{kind: KindObject, obj: o}
+}
+
+func (v Value) Kind() Kind { return v.kind }
+
+func (v Value) IsNil() bool    { return v.kind == KindNil }
+func (v Value) IsBool() bool   { return v.kind == KindBool }
+func (v Value) IsNumber() bool { return v.kind == KindNumber }
+func (v Value) IsString() bool { return v.kind == KindString }
+func (v Value) IsObject() bool { return v.kind == KindObject }
+
+// AsBool returns the bool of a bool value, and false for any other value
+func (v Value) AsBool() bool { return v.kind == KindBool && v.num != 0 }
+
+// AsNumber returns the number of a number value, and 0 for any other value
+func (v Value) AsNumber() float64 {
+	if v.kind != KindNumber {
+		return 0
+	}
+	return v.num
+}
+
+// AsString returns the string of a string value, and "" for any other value
+func (v Value) AsString() string { return v.str }
+
+// AsObject returns the object of an object value, and nil for any other value
+func (v Value) AsObject() Object { return v.obj }
+
+// Truthy reports whether v counts as true in a condition. Only nil and
+// false are falsey.
+func (v Value) Truthy() bool {
+	switch v.kind {
+	case KindNil:
+		return false
+	case KindBool:
+		return v.num != 0
+	}
+	return true
+}
+
+// Equals reports whether v and o are the same lox value. Values of different
+// types are never equal. Nils, bools, numbers and strings are compared by
+// value, with NaN not equal to anything, and objects by identity. Map keys
+// and match patterns use the same equality.
+func (v Value) Equals(o Value) bool {
+	if v.kind != o.kind {
+		return false
+	}
+	switch v.kind {
+	case KindNil:
+		return true
+	case KindBool, KindNumber:
+		return v.num == o.num
+	case KindString:
+		return v.str == o.str
+	}
+	if !reflect.TypeOf(v.obj).Comparable() {
+		// comparing these would panic, and they can't be the same object
+		// unless they are pointers, which are comparable
+		return false
+	}
+	return v.obj == o.obj
+}
+
+// IsHashable reports whether v can be used as a map key
+func (v Value) IsHashable() bool {
+	switch v.kind {
+	case KindNil, KindBool, KindString:
+		return true
+	case KindNumber:
+		return !math.IsNaN(v.num)
+	}
+	return false
+}
+
+// TypeName is the name of the type of v used in runtime errors
+func (v Value) TypeName() string {
+	switch v.kind {
+	case KindNil:
+		return "nil"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	}
+	return v.obj.TypeName()
+}
+
+// Interface returns v as a plain Go value: nil, bool, float64, string or
+// the Object
+func (v Value) Interface() interface{} {
+	switch v.kind {
+	case KindNil:
+		return nil
+	case KindBool:
+		return v.num != 0
+	case KindNumber:
+		return v.num
+	case KindString:
+		return v.str
+	}
+	return v.obj
+}
+
+// String formats v the way print shows it. Numbers with no fractional part
+// don't have a trailing `.0` or an exponent, and objects format themselves
+// with a String method, or as `<type>` if they don't have one.
+func (v Value) String() string {
+	switch v.kind {
+	case KindNil:
+		return "nil"
+	case KindBool:
+		return strconv.FormatBool(v.num != 0)
+	case KindNumber:
+		return FormatNumber(v.num)
+	case KindString:
+		return v.str
+	}
+	if s, ok := v.obj.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return "<" + v.obj.TypeName() + ">"
+}
+
+// FormatNumber formats n like lox prints it. Integers have no fraction or
+// exponent, so 1e6 is 1000000, but very large and very small numbers use
+// exponents.
+func FormatNumber(n float64) string {
+	switch {
+	case math.IsNaN(n):
+		return "NaN"
+	case math.IsInf(n, 1):
+		return "Infinity"
+	case math.IsInf(n, -1):
+		return "-Infinity"
+	}
+	if abs := math.Abs(n); abs == 0 || (abs >= 1e-7 && abs < 1e21) {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
@@ -0,0 +1,95 @@
+package value
+
+import (
+	"math"
+	"testing"
+)
+
+type testObject struct{ name string }
+
+func (o *testObject) TypeName() string { return "test" }
+
+type uncomparable []int
+
+func (u uncomparable) TypeName() string { return "uncomparable" }
+
+func TestTruthy(t *testing.T) {
+	values := []Value{Nil, NewBool(false), NewBool(true), NewNumber(0), NewString(""), NewObject(&testObject{})}
+	expected := []bool{false, false, true, true, true, true}
+	for idx, v := range values {
+		if v.Truthy() != expected[idx] {
+			t.Errorf("%v: expected truthy to be %v", v, expected[idx])
+		}
+	}
+}
+
+func TestEquals(t *testing.T) {
+	obj := &testObject{}
+	equal := [][2]Value{
+		{Nil, Nil},
+		{NewBool(true), NewBool(true)},
+		{NewNumber(1), NewNumber(1)},
+		{NewString("a"), NewString("a")},
+		{NewObject(obj), NewObject(obj)},
+		{NewObject(nil), Nil},
+	}
+	for _, c := range equal {
+		if !c[0].Equals(c[1]) {
+			t.Errorf("Expected %v to equal %v", c[0], c[1])
+		}
+	}
+	different := [][2]Value{
+		{Nil, NewBool(false)},
+		{NewNumber(1), NewBool(true)},
+		{NewNumber(0), NewString("")},
+		{NewString("a"), NewString("b")},
+		{NewObject(obj), NewObject(&testObject{})},
+		{NewNumber(math.NaN()), NewNumber(math.NaN())},
+		{NewObject(uncomparable{}), NewObject(uncomparable{})},
+	}
+	for _, c := range different {
+		if c[0].Equals(c[1]) {
+			t.Errorf("Expected %v to not equal %v", c[0], c[1])
+		}
+	}
+}
+
+func TestTypeNames(t *testing.T) {
+	values := []Value{Nil, NewBool(true), NewNumber(1), NewString("s"), NewObject(&testObject{})}
+	expected := []string{"nil", "bool", "number", "string", "test"}
+	for idx, v := range values {
+		if v.TypeName() != expected[idx] {
+			t.Errorf("Expected type name %s, got: %s", expected[idx], v.TypeName())
+		}
+	}
+}
+
+func TestInterface(t *testing.T) {
+	obj := &testObject{}
+	values := []Value{Nil, NewBool(true), NewNumber(1.5), NewString("s"), NewObject(obj)}
+	expected := []interface{}{nil, true, 1.5, "s", obj}
+	for idx, v := range values {
+		if v.Interface() != expected[idx] {
+			t.Errorf("Expected %v, got: %v", expected[idx], v.Interface())
+		}
+	}
+}
+
+func TestIsHashable(t *testing.T) {
+	if !NewNumber(1).IsHashable() || !NewString("").IsHashable() || !Nil.IsHashable() {
+		t.Errorf("Expected nil, numbers and strings to be hashable")
+	}
+	if NewNumber(math.NaN()).IsHashable() || NewObject(&testObject{}).IsHashable() {
+		t.Errorf("Expected NaN and objects to not be hashable")
+	}
+}
+
+func TestString(t *testing.T) {
+	values := []Value{Nil, NewBool(false), NewNumber(1e6), NewNumber(0.1), NewString("s"), NewObject(&testObject{})}
+	expected := []string{"nil", "false", "1000000", "0.1", "s", "<test>"}
+	for idx, v := range values {
+		if v.String() != expected[idx] {
+			t.Errorf("Expected %s, got: %s", expected[idx], v.String())
+		}
+	}
+}